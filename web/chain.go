@@ -0,0 +1,77 @@
+package web
+
+import "math"
+
+// abortIndex Abort之后index跳到这里 后续的处理函数都不再执行
+const abortIndex = math.MaxInt / 2
+
+// callNext MiddlewareFunc中的next 交给Context继续执行处理链
+func callNext(ctx *Context) {
+	ctx.Next()
+}
+
+// WrapMiddlewareFunc 把 func(HandlerFunc) HandlerFunc 形式的中间件转换为处理链中的一环
+// 中间件没有调用next时视为中断 后面的处理函数不再执行
+func WrapMiddlewareFunc(m MiddlewareFunc) HandlerFunc {
+	h := m(callNext)
+	return func(ctx *Context) {
+		index := ctx.index
+		h(ctx)
+		if ctx.index == index {
+			ctx.Abort()
+		}
+	}
+}
+
+// appendMiddlewares 按执行顺序追加 后添加的MiddlewareFunc在外层 先执行
+func appendMiddlewares(chain []HandlerFunc, middlewares []MiddlewareFunc) []HandlerFunc {
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		chain = append(chain, WrapMiddlewareFunc(middlewares[i]))
+	}
+	return chain
+}
+
+// wrapMiddlewares 直接套上MiddlewareFunc 后添加的在外层
+func wrapMiddlewares(handlerFunc HandlerFunc, middlewares []MiddlewareFunc) HandlerFunc {
+	for _, middleware := range middlewares {
+		handlerFunc = middleware(handlerFunc)
+	}
+	return handlerFunc
+}
+
+func (c *Context) handle(handlers []HandlerFunc) {
+	c.handlers = handlers
+	c.index = -1
+	c.Next()
+}
+
+// Next 执行处理链中剩余的处理函数 只能在中间件中调用
+func (c *Context) Next() {
+	c.index++
+	for c.index < len(c.handlers) {
+		c.handlers[c.index](c)
+		c.index++
+	}
+}
+
+// Abort 中断处理链 当前处理函数仍会执行完 之后的不再执行
+func (c *Context) Abort() {
+	c.index = abortIndex
+}
+
+func (c *Context) IsAborted() bool {
+	return c.index >= abortIndex
+}
+
+// AbortWithStatus 中断处理链并写入状态码
+func (c *Context) AbortWithStatus(code int) {
+	c.Abort()
+	c.W.WriteHeader(code)
+	c.StatusCode = code
+}
+
+// AbortWithStatusJSON 中断处理链并返回Json
+func (c *Context) AbortWithStatusJSON(code int, obj any) error {
+	c.Abort()
+	return c.JsonTemplate(code, obj)
+}
@@ -0,0 +1,120 @@
+package web
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// 参数约束 /users/:id<int> /files/:name<[a-z0-9_-]+> /posts/:slug<uuid>
+// 不满足约束的请求继续尝试其他路由 约束中不能包含 /
+
+type constraintKind uint8
+
+const (
+	noConstraint constraintKind = iota
+	intConstraint
+	floatConstraint
+	uuidConstraint
+	alphaConstraint
+	regexpConstraint
+)
+
+type paramConstraint struct {
+	// 注册时 <> 中的原文
+	expr string
+	kind constraintKind
+	re   *regexp.Regexp
+}
+
+// parseParam 拆分 :id<int> 为参数名和约束
+func parseParam(seg string) (string, *paramConstraint, error) {
+	key := seg[1:]
+	i := strings.IndexByte(key, '<')
+	if i < 0 {
+		return key, nil, nil
+	}
+	if i == 0 || !strings.HasSuffix(key, ">") || i+2 >= len(key) {
+		return "", nil, fmt.Errorf("invalid param constraint %s", seg)
+	}
+	c := &paramConstraint{expr: key[i+1 : len(key)-1]}
+	key = key[:i]
+	switch c.expr {
+	case "int":
+		c.kind = intConstraint
+	case "float":
+		c.kind = floatConstraint
+	case "uuid":
+		c.kind = uuidConstraint
+	case "alpha":
+		c.kind = alphaConstraint
+	default:
+		re, err := regexp.Compile("^(?:" + c.expr + ")$")
+		if err != nil {
+			return "", nil, fmt.Errorf("invalid param constraint %s: %v", seg, err)
+		}
+		c.kind, c.re = regexpConstraint, re
+	}
+	return key, c, nil
+}
+
+func (c *paramConstraint) String() string {
+	if c == nil {
+		return ""
+	}
+	return c.expr
+}
+
+// check 校验参数 带类型的约束同时把解析后的值存入p
+func (c *paramConstraint) check(p *Param) bool {
+	if c == nil {
+		return true
+	}
+	p.kind = c.kind
+	switch c.kind {
+	case intConstraint:
+		i, err := strconv.ParseInt(p.Value, 10, 64)
+		p.intValue = i
+		return err == nil
+	case floatConstraint:
+		f, err := strconv.ParseFloat(p.Value, 64)
+		p.floatValue = f
+		return err == nil
+	case uuidConstraint:
+		return isUUID(p.Value)
+	case alphaConstraint:
+		for i := 0; i < len(p.Value); i++ {
+			if b := p.Value[i] | 0x20; b < 'a' || b > 'z' {
+				return false
+			}
+		}
+		return true
+	default:
+		return c.re.MatchString(p.Value)
+	}
+}
+
+// isUUID 8-4-4-4-12 格式的十六进制
+func isUUID(s string) bool {
+	if len(s) != 36 {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		switch i {
+		case 8, 13, 18, 23:
+			if s[i] != '-' {
+				return false
+			}
+		default:
+			if !isHex(s[i]) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func isHex(b byte) bool {
+	return '0' <= b && b <= '9' || 'a' <= b && b <= 'f' || 'A' <= b && b <= 'F'
+}
@@ -1,6 +1,7 @@
 package web
 
 import (
+	"crypto/x509"
 	"errors"
 	"github.com/MucOtto/web/binding"
 	mylog "github.com/MucOtto/web/log"
@@ -12,13 +13,15 @@ import (
 	"net/http"
 	"net/url"
 	"os"
+	"strconv"
 	"strings"
+	"sync"
 )
 
 const defaultMemory = 64 << 20
 
 type Context struct {
-	W                     http.ResponseWriter
+	W                     ResponseWriter
 	R                     *http.Request
 	engine                *Engine
 	queryCache            url.Values
@@ -27,14 +30,119 @@ type Context struct {
 	DisallowLessFiles     bool
 	StatusCode            int
 	Logger                *mylog.Logger
+	params                Params
+	// 当前请求的处理链以及执行到的位置
+	handlers []HandlerFunc
+	index    int
+	// Set保存的数据 请求结束后清空
+	Keys      map[string]any
+	keysMutex sync.RWMutex
+	// W 默认指向这里 随Context一起复用
+	writer responseWriter
+}
+
+// reset 从池中取出后清空上一个请求留下的全部状态
+func (c *Context) reset(w http.ResponseWriter, r *http.Request) {
+	c.writer.reset(w)
+	c.W = &c.writer
+	c.R = r
+	c.queryCache = nil
+	c.formCache = nil
+	c.DisallowUnknownFields = false
+	c.DisallowLessFiles = false
+	c.StatusCode = 0
+	c.params = c.params[:0]
+	c.handlers = nil
+	c.index = -1
+	c.Keys = nil
+}
+
+// Copy 复制一份可以在处理函数返回后继续使用的Context 用于启动的协程或者异步任务
+// Context会在请求结束后放回池中复用 不能直接交给比请求活得更久的代码
+// 副本不能再写响应
+func (c *Context) Copy() *Context {
+	cp := &Context{
+		R:                     c.R,
+		engine:                c.engine,
+		DisallowUnknownFields: c.DisallowUnknownFields,
+		DisallowLessFiles:     c.DisallowLessFiles,
+		StatusCode:            c.StatusCode,
+		Logger:                c.Logger,
+		index:                 abortIndex,
+	}
+	cp.writer.reset(nil)
+	cp.W = &cp.writer
+	cp.params = append(Params(nil), c.params...)
+	c.keysMutex.RLock()
+	if c.Keys != nil {
+		cp.Keys = make(map[string]any, len(c.Keys))
+		for k, v := range c.Keys {
+			cp.Keys[k] = v
+		}
+	}
+	c.keysMutex.RUnlock()
+	return cp
+}
+
+// Param 获取路由参数 /user/:id 中的 id
+func (c *Context) Param(name string) string {
+	value, _ := c.params.Get(name)
+	return value
+}
+
+// Params 获取当前请求匹配到的全部路由参数
+func (c *Context) Params() Params {
+	return c.params
+}
+
+func (c *Context) ParamInt(name string) (int, error) {
+	if p, ok := c.params.param(name); ok && p.kind == intConstraint {
+		return int(p.intValue), nil
+	}
+	return strconv.Atoi(c.Param(name))
+}
+
+func (c *Context) ParamInt64(name string) (int64, error) {
+	if p, ok := c.params.param(name); ok && p.kind == intConstraint {
+		return p.intValue, nil
+	}
+	return strconv.ParseInt(c.Param(name), 10, 64)
+}
+
+func (c *Context) ParamFloat64(name string) (float64, error) {
+	if p, ok := c.params.param(name); ok && p.kind == floatConstraint {
+		return p.floatValue, nil
+	}
+	return strconv.ParseFloat(c.Param(name), 64)
+}
+
+// ParamValue 返回按约束解析后的参数 <int> 为int64 <float> 为float64 其余为string
+func (c *Context) ParamValue(name string) any {
+	p, ok := c.params.param(name)
+	if !ok {
+		return nil
+	}
+	switch p.kind {
+	case intConstraint:
+		return p.intValue
+	case floatConstraint:
+		return p.floatValue
+	default:
+		return p.Value
+	}
+}
+
+func (c *Context) ParamBool(name string) (bool, error) {
+	return strconv.ParseBool(c.Param(name))
 }
 
 // BindJson 前后端Json格式获取解析
 func (c *Context) BindJson(obj any) error {
-	jsonBinding := &binding.JSON
+	// 复制一份再修改 不能改动全局的binding.JSON 否则并发请求之间会互相影响
+	jsonBinding := binding.JSON
 	jsonBinding.DisallowUnknownFields = c.DisallowUnknownFields
 	jsonBinding.DisallowLessFiles = c.DisallowLessFiles
-	return c.BindWith(obj, jsonBinding)
+	return c.BindWith(obj, &jsonBinding)
 }
 
 func (c *Context) BindXml(obj any) error {
@@ -72,6 +180,9 @@ func (c *Context) GetQueryArray(key string) (values []string, ok bool) {
 }
 
 func (c *Context) initQueryCache() {
+	if c.queryCache != nil {
+		return
+	}
 	if c.R != nil {
 		c.queryCache = c.R.URL.Query()
 	} else {
@@ -96,6 +207,9 @@ func (c *Context) GetFormArray(key string) (values []string, ok bool) {
 }
 
 func (c *Context) initFormCache() {
+	if c.formCache != nil {
+		return
+	}
 	if c.R != nil {
 		if err := c.R.ParseMultipartForm(defaultMemory); err != nil {
 			// 是否发生了未上传文件之外的其他错误
@@ -192,7 +306,7 @@ func (c *Context) FileFromFS(filepath string, fs http.FileSystem) {
 
 // Redirect 重定向
 func (c *Context) Redirect(status int, location string) {
-	c.Render(http.StatusOK, &render.Redirect{
+	c.Render(status, &render.Redirect{
 		Code:     status,
 		Location: location,
 		Request:  c.R,
@@ -234,3 +348,25 @@ func (c *Context) HandleWithError(code int, obj any, err error) {
 
 	c.JsonTemplate(code, obj)
 }
+
+// ClientCertificate 通过校验的客户端证书 非TLS请求或者没有校验客户端证书时返回nil
+func (c *Context) ClientCertificate() *x509.Certificate {
+	chains := c.VerifiedChains()
+	if len(chains) == 0 || len(chains[0]) == 0 {
+		return nil
+	}
+	return chains[0][0]
+}
+
+// VerifiedChains 客户端证书校验通过的证书链 每条链的第一个是客户端证书 最后一个是CA
+func (c *Context) VerifiedChains() [][]*x509.Certificate {
+	if c.R == nil || c.R.TLS == nil {
+		return nil
+	}
+	return c.R.TLS.VerifiedChains
+}
+
+// Push HTTP/2服务端推送 HTTP/1.1等不支持推送时返回 http.ErrNotSupported 调用方可以直接忽略
+func (c *Context) Push(target string, opts *http.PushOptions) error {
+	return c.W.Push(target, opts)
+}
@@ -0,0 +1,71 @@
+package web
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestContextReset(t *testing.T) {
+	engine := New()
+	// 去掉会打印日志的默认中间件
+	engine.Middleware = nil
+	engine.Group("").Post("/echo", func(ctx *Context) {
+		id := ctx.R.URL.Query().Get("id")
+		if ctx.StatusCode != 0 || ctx.DisallowUnknownFields || ctx.Keys != nil {
+			ctx.String(http.StatusInternalServerError, "dirty context")
+			return
+		}
+		if got := ctx.GetQuery("id"); got != id {
+			ctx.String(http.StatusInternalServerError, "query %v", got)
+			return
+		}
+		if got := ctx.GetForm("name"); got != "" {
+			ctx.String(http.StatusInternalServerError, "form %v", got)
+			return
+		}
+		n, _ := strconv.Atoi(id)
+		// 偶数请求拒绝未知字段 奇数请求允许
+		ctx.DisallowUnknownFields = n%2 == 0
+		ctx.Set("id", id)
+		var body struct {
+			ID string `json:"id"`
+		}
+		if err := ctx.BindJson(&body); err != nil {
+			ctx.String(http.StatusBadRequest, "bad")
+			return
+		}
+		if body.ID != ctx.GetString("id") {
+			ctx.String(http.StatusInternalServerError, "body %s", body.ID)
+			return
+		}
+		ctx.String(http.StatusOK, "%s", body.ID)
+	})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 200; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			id := strconv.Itoa(i)
+			body := fmt.Sprintf(`{"id":%q,"extra":1}`, id)
+			r := httptest.NewRequest(http.MethodPost, "/echo?id="+id, strings.NewReader(body))
+			r.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			engine.ServeHTTP(w, r)
+
+			want, code := id, http.StatusOK
+			if i%2 == 0 {
+				want, code = "bad", http.StatusBadRequest
+			}
+			if w.Code != code || w.Body.String() != want {
+				t.Errorf("request %d: %d %q, want %d %q", i, w.Code, w.Body.String(), code, want)
+			}
+		}(i)
+	}
+	wg.Wait()
+}
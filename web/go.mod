@@ -2,7 +2,10 @@ module github.com/MucOtto/web
 
 go 1.22.2
 
-require github.com/go-playground/validator/v10 v10.20.0
+require (
+	github.com/go-playground/validator/v10 v10.20.0
+	golang.org/x/net v0.21.0
+)
 
 require (
 	github.com/gabriel-vasile/mimetype v1.4.3 // indirect
@@ -10,7 +13,6 @@ require (
 	github.com/go-playground/universal-translator v0.18.1 // indirect
 	github.com/leodido/go-urn v1.4.0 // indirect
 	golang.org/x/crypto v0.19.0 // indirect
-	golang.org/x/net v0.21.0 // indirect
 	golang.org/x/sys v0.17.0 // indirect
 	golang.org/x/text v0.14.0 // indirect
 )
@@ -0,0 +1,17 @@
+package web
+
+import (
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
+	"net/http"
+)
+
+// configureH2C 明文连接上识别HTTP/2请求 Shutdown时同样通知HTTP/2连接优雅关闭
+func (e *Engine) configureH2C(srv *http.Server) error {
+	h2s := &http2.Server{}
+	if err := http2.ConfigureServer(srv, h2s); err != nil {
+		return err
+	}
+	srv.Handler = h2c.NewHandler(e, h2s)
+	return nil
+}
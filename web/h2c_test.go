@@ -0,0 +1,71 @@
+package web
+
+import (
+	"context"
+	"crypto/tls"
+	"golang.org/x/net/http2"
+	"io"
+	"net"
+	"net/http"
+	"sync"
+	"testing"
+)
+
+func TestH2C(t *testing.T) {
+	engine := New()
+	engine.Middleware = nil
+	engine.UseH2C = true
+	var mu sync.Mutex
+	var protos []string
+	engine.MiddlewareHandle(func(next HandlerFunc) HandlerFunc {
+		return LoggingWithConfig(&LoggerConfig{
+			Formatter: func(params *LogFormatterParams) string {
+				mu.Lock()
+				protos = append(protos, params.Proto)
+				mu.Unlock()
+				return ""
+			},
+		}, next)
+	})
+	engine.Group("").Get("/push", func(ctx *Context) {
+		// HTTP/1.1 不支持推送 HTTP/2 客户端也可能关闭推送 都只返回错误
+		err := ctx.Push("/app.css", nil)
+		if ctx.R.ProtoMajor == 1 && err != http.ErrNotSupported {
+			t.Errorf("Push over %s: %v", ctx.R.Proto, err)
+		}
+		ctx.String(http.StatusOK, "%s", ctx.R.Proto)
+	})
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	runErr := make(chan error, 1)
+	go func() { runErr <- engine.RunListener(l) }()
+	url := "http://" + l.Addr().String() + "/push"
+
+	h2 := &http.Client{Transport: &http2.Transport{
+		AllowHTTP: true,
+		DialTLSContext: func(ctx context.Context, network, addr string, _ *tls.Config) (net.Conn, error) {
+			return (&net.Dialer{}).DialContext(ctx, network, addr)
+		},
+	}}
+	for _, client := range []*http.Client{h2, {}} {
+		resp, err := client.Get(url)
+		if err != nil {
+			t.Fatal(err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if string(body) != resp.Proto {
+			t.Errorf("served %s over %s", body, resp.Proto)
+		}
+	}
+	engine.Shutdown(context.Background())
+	if err := <-runErr; err != nil {
+		t.Errorf("RunListener error %v", err)
+	}
+	if len(protos) != 2 || protos[0] != "HTTP/2.0" || protos[1] != "HTTP/1.1" {
+		t.Errorf("logged protocols %v", protos)
+	}
+}
@@ -0,0 +1,124 @@
+package web
+
+import (
+	"fmt"
+	"strings"
+)
+
+// hostPattern 分组绑定的域名 api.example.com :tenant.example.com *.example.com
+type hostPattern struct {
+	pattern string
+	labels  []string
+	params  int
+	// * 的个数 不产生参数 但和 :name 一样不是精确匹配
+	wilds int
+	// 绑定该域名的全部分组共用的路由树
+	tree routeTree
+}
+
+func newHostPattern(pattern string) (*hostPattern, error) {
+	h := &hostPattern{pattern: pattern}
+	for _, label := range strings.Split(strings.TrimSuffix(pattern, "."), ".") {
+		switch {
+		case label == "" || label == ":":
+			return nil, fmt.Errorf("invalid host pattern %s", pattern)
+		case label[0] == ':':
+			h.params++
+		case label == "*":
+			h.wilds++
+		default:
+			label = strings.ToLower(label)
+		}
+		h.labels = append(h.labels, label)
+	}
+	return h, nil
+}
+
+// lessSpecific 不精确的标签更多 个数相同时 * 多的排在后面
+func (h *hostPattern) lessSpecific(other *hostPattern) bool {
+	if n, m := h.params+h.wilds, other.params+other.wilds; n != m {
+		return n > m
+	}
+	return h.wilds > other.wilds
+}
+
+// match 逐段比较域名 :name 匹配到的部分追加到params中 失败时撤销
+func (h *hostPattern) match(host string, params *Params) bool {
+	l := len(*params)
+	for i, label := range h.labels {
+		part := host
+		if i < len(h.labels)-1 {
+			j := strings.IndexByte(host, '.')
+			if j < 0 {
+				*params = (*params)[:l]
+				return false
+			}
+			part, host = host[:j], host[j+1:]
+		} else if strings.IndexByte(part, '.') >= 0 {
+			*params = (*params)[:l]
+			return false
+		}
+		switch {
+		case part == "":
+			*params = (*params)[:l]
+			return false
+		case label[0] == ':':
+			*params = append(*params, Param{Key: label[1:], Value: part})
+		case label == "*":
+		case !strings.EqualFold(part, label):
+			*params = (*params)[:l]
+			return false
+		}
+	}
+	return true
+}
+
+// stripHostPort 去掉端口和末尾的点 兼容 [::1]:8080 这样的地址
+func stripHostPort(host string) string {
+	if i := strings.LastIndexByte(host, ':'); i > strings.LastIndexByte(host, ']') {
+		host = host[:i]
+	}
+	return strings.TrimSuffix(host, ".")
+}
+
+// Host 创建绑定域名的分组 域名中的 :name 可以通过Context.Param获取
+// 没有匹配到任何绑定域名的请求交给普通分组处理
+func (r *router) Host(pattern string) *routerGroup {
+	var host *hostPattern
+	for _, h := range r.hosts {
+		if h.pattern == pattern {
+			host = h
+			break
+		}
+	}
+	if host == nil {
+		h, err := newHostPattern(pattern)
+		if err != nil {
+			panic(fmt.Sprintf("web: %v", err))
+		}
+		host = h
+		// 精确的域名优先 其次是 :name 和 * 少的
+		i := len(r.hosts)
+		for i > 0 && r.hosts[i-1].lessSpecific(host) {
+			i--
+		}
+		r.hosts = append(r.hosts, nil)
+		copy(r.hosts[i+1:], r.hosts[i:])
+		r.hosts[i] = host
+	}
+	return r.newGroup(nil, host, "")
+}
+
+// hostTree 返回请求域名对应的路由树 域名参数追加到params中
+func (r *router) hostTree(host string, params *Params) *routeTree {
+	if len(r.hosts) == 0 {
+		return &r.tree
+	}
+	host = stripHostPort(host)
+	for _, h := range r.hosts {
+		if h.match(host, params) {
+			return &h.tree
+		}
+	}
+	return &r.tree
+}
@@ -6,7 +6,12 @@ import (
 	"github.com/MucOtto/web/render"
 	"html/template"
 	"net/http"
+	"net/url"
+	"sort"
+	"strings"
 	"sync"
+	"sync/atomic"
+	"time"
 )
 
 type HandlerFunc func(ctx *Context)
@@ -14,37 +19,112 @@ type HandlerFunc func(ctx *Context)
 type MiddlewareFunc func(handlerFunc HandlerFunc) HandlerFunc
 
 type routerGroup struct {
-	name              string
+	name string
+	// 包含所有父分组的完整前缀 如 /api/v1 根分组为空
+	prefix string
+	parent *routerGroup
+	// 绑定的域名 为空时处理未匹配任何域名的请求
+	host              *hostPattern
 	handlerFuncMap    map[string]map[string]HandlerFunc
 	middlewareFuncMap map[string]map[string][]MiddlewareFunc // 中间件和路由的映射
-	treeNode          *treeNode
 	Middlewares       []MiddlewareFunc
+	// Use添加的 Next/Abort 风格的中间件
+	handlers []HandlerFunc
+	// 不经过引擎中间件的分组以及路由
+	excludeGlobal  bool
+	excludedRoutes map[string]map[string]bool
+	engine         *Engine
 }
 
+// MiddlewareHandle 添加分组中间件 已经注册的路由会重新组装处理链
+// 直接修改Middlewares字段不会生效 需要等到Run时统一重建
 func (g *routerGroup) MiddlewareHandle(middlewareFunc ...MiddlewareFunc) {
 	g.Middlewares = append(g.Middlewares, middlewareFunc...)
+	g.engine.rebuildChains()
 }
 
-func (g *routerGroup) MethodHandle(name string, method string, handlerFunc HandlerFunc, ctx *Context) {
-	// 所有方法适用的中间件
-	if len(g.Middlewares) > 0 {
-		for _, Middleware := range g.Middlewares {
-			handlerFunc = Middleware(handlerFunc)
+// Use 添加 Next/Abort 风格的中间件 按添加顺序执行 位于同一分组的MiddlewareFunc之后
+func (g *routerGroup) Use(handlers ...HandlerFunc) {
+	g.handlers = append(g.handlers, handlers...)
+	g.engine.rebuildChains()
+}
+
+// ExcludeGlobalMiddleware 分组及其子分组下的路由不再经过引擎中间件
+func (g *routerGroup) ExcludeGlobalMiddleware() *routerGroup {
+	g.excludeGlobal = true
+	g.engine.rebuildChains()
+	return g
+}
+
+// globalExcluded 路由本身或者任一上级分组排除了引擎中间件
+func (g *routerGroup) globalExcluded(name string, method string) bool {
+	if g.excludedRoutes[name][method] {
+		return true
+	}
+	for group := g; group != nil; group = group.parent {
+		if group.excludeGlobal {
+			return true
 		}
 	}
+	return false
+}
 
-	// 各自路由对应的中间件
-	middlewareFuncs, ok := g.middlewareFuncMap[name][method]
-	if ok && len(middlewareFuncs) > 0 {
-		for _, Middleware := range middlewareFuncs {
-			handlerFunc = Middleware(handlerFunc)
-		}
+// buildChain 按执行顺序组装处理链 引擎 -> 父分组 -> 本分组 -> 路由中间件 -> 处理函数
+// MiddlewareFunc 仍然是后添加的在外层
+func (g *routerGroup) buildChain(name string, method string) []HandlerFunc {
+	var groups []*routerGroup
+	for group := g; group != nil; group = group.parent {
+		groups = append(groups, group)
+	}
+	var chain []HandlerFunc
+	if !g.globalExcluded(name, method) {
+		chain = appendMiddlewares(chain, g.engine.Middleware)
+		chain = append(chain, g.engine.handlers...)
 	}
+	for i := len(groups) - 1; i >= 0; i-- {
+		chain = appendMiddlewares(chain, groups[i].Middlewares)
+		chain = append(chain, groups[i].handlers...)
+	}
+	chain = appendMiddlewares(chain, g.middlewareFuncMap[name][method])
+	return append(chain, g.handlerFuncMap[name][method])
+}
+
+// Group 创建子分组 前缀拼接在当前分组之后 中间件在父分组的基础上扩展
+func (g *routerGroup) Group(name string) *routerGroup {
+	return g.engine.newGroup(g, g.host, name)
+}
 
-	handlerFunc(ctx)
+// tree 分组所属域名的路由树 同一域名下的分组共用一棵
+func (g *routerGroup) tree() *routeTree {
+	if g.host != nil {
+		return &g.host.tree
+	}
+	return &g.engine.tree
+}
+
+// joinPath 分组前缀加上路由 分组内的 / 对应前缀本身 /api 分组的 / 即 /api
+func joinPath(prefix, name string) string {
+	if name == "/" && prefix != "" {
+		return prefix
+	}
+	return prefix + name
 }
 
-func (r *routerGroup) handle(name string, method string, _handlerFunc HandlerFunc, middlewares ...MiddlewareFunc) {
+// handle 注册路由 重复注册或者和已有路由冲突时直接panic
+func (r *routerGroup) handle(name string, method string, _handlerFunc HandlerFunc, middlewares ...MiddlewareFunc) *Route {
+	if !strings.HasPrefix(name, "/") {
+		name = "/" + name
+	}
+	r.engine.mustNotRun("registering routes")
+	fullPath := joinPath(r.prefix, name)
+	tree := r.tree()
+	if _, ok := tree.routes[fullPath][method]; ok {
+		panic(fmt.Sprintf("web: %s %s is already registered", method, fullPath))
+	}
+	if err := tree.root.Put(fullPath); err != nil {
+		panic(fmt.Sprintf("web: %s %s: %v", method, fullPath, err))
+	}
+
 	_, ok := r.handlerFuncMap[name]
 	if !ok {
 		r.handlerFuncMap[name] = make(map[string]HandlerFunc)
@@ -52,46 +132,185 @@ func (r *routerGroup) handle(name string, method string, _handlerFunc HandlerFun
 	}
 	r.handlerFuncMap[name][method] = _handlerFunc
 	r.middlewareFuncMap[name][method] = append(r.middlewareFuncMap[name][method], middlewares...)
+	tree.add(fullPath, method, &routeEntry{group: r, name: name, chain: r.buildChain(name, method)})
+
+	n := countParams(name)
+	if r.host != nil {
+		n += r.host.params
+	}
+	if n > r.engine.maxParams {
+		r.engine.maxParams = n
+	}
+	route := &Route{group: r, path: name, methods: []string{method}}
+	r.engine.routes = append(r.engine.routes, route)
+	return route
+}
 
-	r.treeNode.Put(name)
+// anyMethod 不区分请求方式的路由 其他方式都没有注册时使用 用于Mount转发 PROPFIND 之类的自定义方法
+const anyMethod = "*"
+
+// anyMethods Any注册时覆盖的请求方式
+var anyMethods = []string{
+	http.MethodGet, http.MethodPost, http.MethodPut, http.MethodPatch,
+	http.MethodHead, http.MethodOptions, http.MethodDelete,
+	http.MethodConnect, http.MethodTrace,
+}
+
+// Handle 注册任意请求方式 包括 PROPFIND 之类的自定义方法
+func (r *routerGroup) Handle(method string, name string, _handlerFunc HandlerFunc, middlewares ...MiddlewareFunc) *Route {
+	if method == "" || strings.ToUpper(method) != method {
+		panic(fmt.Sprintf("web: invalid http method %q", method))
+	}
+	return r.handle(name, method, _handlerFunc, middlewares...)
+}
+
+// Any 注册所有标准请求方式
+func (r *routerGroup) Any(name string, _handlerFunc HandlerFunc, middlewares ...MiddlewareFunc) *Route {
+	return r.Match(anyMethods, name, _handlerFunc, middlewares...)
+}
+
+// Match 同一个处理函数注册多个请求方式 返回的Route包含全部方法
+func (r *routerGroup) Match(methods []string, name string, _handlerFunc HandlerFunc, middlewares ...MiddlewareFunc) *Route {
+	route := &Route{group: r, path: name, methods: methods}
+	for _, method := range methods {
+		route.path = r.Handle(method, name, _handlerFunc, middlewares...).path
+	}
+	return route
 }
 
 // Get get请求方式
-func (r *routerGroup) Get(name string, _handlerFunc HandlerFunc, middlewares ...MiddlewareFunc) {
-	r.handle(name, http.MethodGet, _handlerFunc, middlewares...)
+func (r *routerGroup) Get(name string, _handlerFunc HandlerFunc, middlewares ...MiddlewareFunc) *Route {
+	return r.handle(name, http.MethodGet, _handlerFunc, middlewares...)
+}
+
+func (r *routerGroup) Post(name string, _handlerFunc HandlerFunc, middlewares ...MiddlewareFunc) *Route {
+	return r.handle(name, http.MethodPost, _handlerFunc, middlewares...)
+}
+
+func (r *routerGroup) Put(name string, _handlerFunc HandlerFunc, middlewares ...MiddlewareFunc) *Route {
+	return r.handle(name, http.MethodPut, _handlerFunc, middlewares...)
+}
+
+func (r *routerGroup) Delete(name string, _handlerFunc HandlerFunc, middlewares ...MiddlewareFunc) *Route {
+	return r.handle(name, http.MethodDelete, _handlerFunc, middlewares...)
+}
+
+func (r *routerGroup) Patch(name string, _handlerFunc HandlerFunc, middlewares ...MiddlewareFunc) *Route {
+	return r.handle(name, http.MethodPatch, _handlerFunc, middlewares...)
+}
+
+// Head 未注册时会自动使用Get的处理函数并丢弃响应体
+func (r *routerGroup) Head(name string, _handlerFunc HandlerFunc, middlewares ...MiddlewareFunc) *Route {
+	return r.handle(name, http.MethodHead, _handlerFunc, middlewares...)
+}
+
+func (r *routerGroup) Options(name string, _handlerFunc HandlerFunc, middlewares ...MiddlewareFunc) *Route {
+	return r.handle(name, http.MethodOptions, _handlerFunc, middlewares...)
+}
+
+func (r *routerGroup) Connect(name string, _handlerFunc HandlerFunc, middlewares ...MiddlewareFunc) *Route {
+	return r.handle(name, http.MethodConnect, _handlerFunc, middlewares...)
 }
 
-func (r *routerGroup) Post(name string, _handlerFunc HandlerFunc, middlewares ...MiddlewareFunc) {
-	r.handle(name, http.MethodPost, _handlerFunc, middlewares...)
+func (r *routerGroup) Trace(name string, _handlerFunc HandlerFunc, middlewares ...MiddlewareFunc) *Route {
+	return r.handle(name, http.MethodTrace, _handlerFunc, middlewares...)
 }
 
-func (r *routerGroup) Put(name string, _handlerFunc HandlerFunc, middlewares ...MiddlewareFunc) {
-	r.handle(name, http.MethodPut, _handlerFunc, middlewares...)
+// routeTree 同一域名下全部分组的路由 按完整路径放在一棵树中
+// 静态 > 参数 > 通配 的优先级以及重复注册的检查因此跨分组同样成立
+type routeTree struct {
+	root   treeNode
+	routes map[string]map[string]*routeEntry // 完整路径 -> 请求方式
 }
 
-func (r *routerGroup) Delete(name string, _handlerFunc HandlerFunc, middlewares ...MiddlewareFunc) {
-	r.handle(name, http.MethodDelete, _handlerFunc, middlewares...)
+// routeEntry 某个完整路径上一个请求方式的路由 分组只提供中间件
+type routeEntry struct {
+	group *routerGroup
+	// 分组内注册时的路由 用于查找分组中的处理函数和中间件
+	name string
+	// 注册时已经组装好的处理链 请求时由Context.Next依次调用
+	chain []HandlerFunc
+}
+
+func (t *routeTree) add(fullPath, method string, entry *routeEntry) {
+	if t.routes == nil {
+		t.routes = make(map[string]map[string]*routeEntry)
+	}
+	if t.routes[fullPath] == nil {
+		t.routes[fullPath] = make(map[string]*routeEntry)
+	}
+	t.routes[fullPath][method] = entry
+}
+
+// find 查找路由 params中已有的域名参数会保留
+func (t *routeTree) find(path string, params *Params) *treeNode {
+	l := len(*params)
+	node := t.root.Get(path, params)
+	if node == nil {
+		*params = (*params)[:l]
+	}
+	return node
+}
+
+// allowedMethods 路由上注册的全部方法 用于Allow响应头
+func (t *routeTree) allowedMethods(fullPath string, options bool) string {
+	routes := t.routes[fullPath]
+	methods := make([]string, 0, len(routes)+2)
+	for method := range routes {
+		if method != anyMethod {
+			methods = append(methods, method)
+		}
+	}
+	if _, ok := routes[http.MethodGet]; ok {
+		if _, ok := routes[http.MethodHead]; !ok {
+			methods = append(methods, http.MethodHead)
+		}
+	}
+	if _, ok := routes[http.MethodOptions]; !ok && options {
+		methods = append(methods, http.MethodOptions)
+	}
+	sort.Strings(methods)
+	return strings.Join(methods, ", ")
+}
+
+func (t *routeTree) rebuildChains() {
+	for _, methods := range t.routes {
+		for method, entry := range methods {
+			entry.chain = entry.group.buildChain(entry.name, method)
+		}
+	}
 }
 
 type router struct {
-	routerGroups []*routerGroup
-	engine       *Engine
+	// 没有绑定域名的分组共用的路由树
+	tree routeTree
+	// 分组绑定的域名 参数少的排在前面
+	hosts  []*hostPattern
+	engine *Engine
 }
 
 func (r *router) Group(name string) *routerGroup {
+	return r.newGroup(nil, nil, name)
+}
+
+func (r *router) newGroup(parent *routerGroup, host *hostPattern, name string) *routerGroup {
+	prefix := ""
+	if parent != nil {
+		prefix = parent.prefix
+	}
+	if s := strings.Trim(name, "/"); s != "" {
+		prefix += "/" + s
+	}
 	group := &routerGroup{
 		name:              name,
+		prefix:            prefix,
+		parent:            parent,
+		host:              host,
 		handlerFuncMap:    make(map[string]map[string]HandlerFunc),
 		middlewareFuncMap: make(map[string]map[string][]MiddlewareFunc),
-		treeNode: &treeNode{
-			val:        "/",
-			children:   make([]*treeNode, 0),
-			routerName: "/",
-		},
-		Middlewares: make([]MiddlewareFunc, 0),
-	}
-	group.MiddlewareHandle(r.engine.Middleware...)
-	r.routerGroups = append(r.routerGroups, group)
+		Middlewares:       make([]MiddlewareFunc, 0),
+		engine:            r.engine,
+	}
 	return group
 }
 
@@ -105,17 +324,67 @@ type Engine struct {
 	Logger       *mylog.Logger
 	Middleware   []MiddlewareFunc
 	errorHandler ErrorHandler
+	// HandleMethodNotAllowed 路由存在但方法未注册时返回405并带上Allow头 关闭后按404处理
+	HandleMethodNotAllowed bool
+	// HandleOPTIONS 没有注册OPTIONS处理函数时自动返回该路由允许的方法
+	HandleOPTIONS bool
+	// RedirectTrailingSlash /users/ 不存在而 /users 存在时重定向 反之亦然
+	RedirectTrailingSlash bool
+	// RedirectCleanPath 把 //users/./1 /users/../admin 之类的路径清理后重定向
+	RedirectCleanPath bool
+	// RedirectCaseInsensitive 忽略大小写查找路由 找到后重定向到注册时的写法
+	RedirectCaseInsensitive bool
+	// 404 405 的处理函数以及各自的中间件
+	noRoute             HandlerFunc
+	noRouteMiddlewares  []MiddlewareFunc
+	noMethod            HandlerFunc
+	noMethodMiddlewares []MiddlewareFunc
+	noRouteChain        []HandlerFunc
+	noMethodChain       []HandlerFunc
+	// 套上NoRoute中间件但不含引擎中间件的404处理函数 用于已经在处理链中的请求 如静态文件不存在
+	notFound HandlerFunc
+	// 自动应答OPTIONS的处理链 同样经过引擎中间件 CORS中间件可以在这里补充响应头
+	optionsChain []HandlerFunc
+	// Use添加的 Next/Abort 风格的中间件
+	handlers []HandlerFunc
+	// 通过Route.Name命名的路由
+	namedRoutes map[string]*Route
+	// 按注册顺序记录的全部路由 每个只包含一个方法
+	routes []*Route
+	// PrintRoutes Run启动时通过Logger打印路由表
+	PrintRoutes bool
+	// 所有路由中参数最多的个数 Context按这个容量预先分配参数
+	maxParams int
+	// UseH2C 明文连接也支持HTTP/2 用于内网服务之间的调用 TLS连接总是支持HTTP/2
+	UseH2C bool
+	// ShutdownTimeout 收到 SIGINT SIGTERM 后等待处理中的请求结束的最长时间
+	ShutdownTimeout time.Duration
+	// 正在运行的服务 Shutdown时统一关闭
+	servers       []*http.Server
+	serverMutex   sync.Mutex
+	shutdownHooks []func()
+	shutdownOnce  sync.Once
+	shuttingDown  bool
+	prepareOnce   sync.Once
+	running       atomic.Bool
+	shutdownDone  chan struct{}
+	shutdownErr   error
 }
 
 func New() *Engine {
 	engine := &Engine{
-		router: router{
-			routerGroups: make([]*routerGroup, 0),
-		},
+		HandleMethodNotAllowed: true,
+		HandleOPTIONS:          true,
+		RedirectTrailingSlash:  true,
+		noRoute:                defaultNoRoute,
+		noMethod:               defaultNoMethod,
+		namedRoutes:            make(map[string]*Route),
+		ShutdownTimeout:        10 * time.Second,
+		shutdownDone:           make(chan struct{}),
 	}
 	engine.Logger = mylog.Default()
-	engine.MiddlewareHandle(Logging, Recovery)
 	engine.router.engine = engine
+	engine.MiddlewareHandle(Logging, Recovery)
 	engine.pool.New = func() any {
 		return engine.allocateContext()
 	}
@@ -123,15 +392,20 @@ func New() *Engine {
 }
 
 func (e *Engine) allocateContext() any {
-	return &Context{engine: e}
+	return &Context{engine: e, params: make(Params, 0, e.maxParams)}
 }
 
 func (e *Engine) SetFuncMap(funcMap template.FuncMap) {
 	e.funcMap = funcMap
 }
 
+// LoadTemplate 加载模板 模板中可以用 {{ url "user.show" "id" 42 }} 生成命名路由的地址
 func (e *Engine) LoadTemplate(pattern string) {
-	t := template.Must(template.New("").Funcs(e.funcMap).ParseGlob(pattern))
+	funcMap := template.FuncMap{"url": e.URL}
+	for name, f := range e.funcMap {
+		funcMap[name] = f
+	}
+	t := template.Must(template.New("").Funcs(funcMap).ParseGlob(pattern))
 	e.HTMLRender = &render.HTMLRender{
 		Template: t,
 	}
@@ -139,47 +413,186 @@ func (e *Engine) LoadTemplate(pattern string) {
 
 func (e *Engine) HTTPRequestHandler(context *Context, w http.ResponseWriter, r *http.Request) {
 	method := r.Method
-	for _, group := range e.routerGroups {
-		routerName := SubStringLast(r.URL.Path, "/"+group.name)
-		node := group.treeNode.Get(routerName)
-		if node != nil {
-			handler, ok := group.handlerFuncMap[node.routerName][method]
-			if !ok {
-				w.WriteHeader(http.StatusMethodNotAllowed)
-				fmt.Fprintf(w, "%s %s NOT ALLOWD", r.RequestURI, method)
-				return
+	context.params = context.params[:0]
+	tree := e.hostTree(r.Host, &context.params)
+	// 域名参数之后才是路由参数
+	hostParams := len(context.params)
+	if node := tree.find(r.URL.Path, &context.params); node != nil {
+		routes := tree.routes[node.routerName]
+		entry, ok := routes[method]
+		if !ok && method == http.MethodHead {
+			// HEAD 没有单独注册时使用 GET 的处理函数
+			if entry, ok = routes[http.MethodGet]; ok {
+				context.W = &headResponseWriter{ResponseWriter: context.W}
 			}
-			group.MethodHandle(node.routerName, method, handler, context)
-
+		}
+		if !ok {
+			entry, ok = routes[anyMethod]
+		}
+		if ok {
+			context.handle(entry.chain)
+			return
+		}
+		if method == http.MethodOptions && e.HandleOPTIONS {
+			w.Header().Set("Allow", tree.allowedMethods(node.routerName, e.HandleOPTIONS))
+			context.handle(e.optionsChain)
 			return
 		}
+		if e.HandleMethodNotAllowed {
+			w.Header().Set("Allow", tree.allowedMethods(node.routerName, e.HandleOPTIONS))
+			context.handle(e.noMethodChain)
+			return
+		}
+	} else if method != http.MethodConnect {
+		// // 开头的地址会被浏览器当作其他域名 不做重定向
+		if location, ok := e.fixedPath(tree, r.URL.Path, &context.params); ok && !strings.HasPrefix(location, "//") {
+			e.redirectFixedPath(context, location)
+			return
+		}
+	}
+	context.params = context.params[:hostParams]
+	context.handle(e.noRouteChain)
+}
+
+// fixedPath 路由不存在时 按配置清理路径 补全或去掉末尾的斜杠 忽略大小写 找出能匹配的规范路径
+func (e *Engine) fixedPath(tree *routeTree, path string, params *Params) (string, bool) {
+	p := path
+	if e.RedirectCleanPath {
+		p = CleanPath(path)
+		if p != path {
+			if node := tree.find(p, params); node != nil {
+				return p, true
+			}
+		}
+	}
+	candidates := []string{p}
+	if e.RedirectTrailingSlash && p != "/" {
+		tsr := p + "/"
+		if strings.HasSuffix(p, "/") {
+			tsr = p[:len(p)-1]
+		}
+		if node := tree.find(tsr, params); node != nil {
+			return tsr, true
+		}
+		candidates = append(candidates, tsr)
+	}
+	if e.RedirectCaseInsensitive {
+		for _, candidate := range candidates {
+			if fixed, ok := tree.root.findCaseInsensitive(candidate); ok {
+				return fixed, true
+			}
+		}
+	}
+	return "", false
+}
+
+// redirectFixedPath GET请求使用301 其他请求使用308保留请求方法和请求体
+func (e *Engine) redirectFixedPath(ctx *Context, location string) {
+	code := http.StatusPermanentRedirect
+	if ctx.R.Method == http.MethodGet || ctx.R.Method == http.MethodHead {
+		code = http.StatusMovedPermanently
+	}
+	// 路径是解码后的 需要重新转义 /\evil.com 中的反斜杠会被浏览器当作 /
+	location = (&url.URL{Path: location}).EscapedPath()
+	if ctx.R.URL.RawQuery != "" {
+		location += "?" + ctx.R.URL.RawQuery
+	}
+	// 重定向很少发生 不预先组装
+	ctx.handle(e.buildChain(func(ctx *Context) {
+		ctx.Redirect(code, location)
+	}, nil))
+}
+
+// buildChain 404 405 等不属于任何分组的处理函数 只经过引擎的中间件
+func (e *Engine) buildChain(handlerFunc HandlerFunc, middlewares []MiddlewareFunc) []HandlerFunc {
+	chain := appendMiddlewares(nil, e.Middleware)
+	chain = append(chain, e.handlers...)
+	chain = appendMiddlewares(chain, middlewares)
+	return append(chain, handlerFunc)
+}
 
+// rebuildChains 中间件变化后重新组装所有路由的处理链
+func (e *Engine) rebuildChains() {
+	e.mustNotRun("changing middleware")
+	e.tree.rebuildChains()
+	for _, host := range e.hosts {
+		host.tree.rebuildChains()
 	}
-	w.WriteHeader(http.StatusNotFound)
-	fmt.Fprintf(w, "%s NOT FOUND", r.URL.Path)
-	return
+	e.noRouteChain = e.buildChain(e.noRoute, e.noRouteMiddlewares)
+	e.noMethodChain = e.buildChain(e.noMethod, e.noMethodMiddlewares)
+	e.notFound = wrapMiddlewares(e.noRoute, e.noRouteMiddlewares)
+	e.optionsChain = e.buildChain(defaultOptions, nil)
+}
+
+// NoRoute 自定义404处理函数 可以用Render返回Json或者HTML
+func (e *Engine) NoRoute(handlerFunc HandlerFunc, middlewares ...MiddlewareFunc) {
+	e.mustNotRun("NoRoute")
+	e.noRoute = handlerFunc
+	e.noRouteMiddlewares = middlewares
+	e.noRouteChain = e.buildChain(e.noRoute, e.noRouteMiddlewares)
+	e.notFound = wrapMiddlewares(e.noRoute, e.noRouteMiddlewares)
+}
+
+// NoMethod 自定义405处理函数 调用前已经设置好Allow响应头
+func (e *Engine) NoMethod(handlerFunc HandlerFunc, middlewares ...MiddlewareFunc) {
+	e.mustNotRun("NoMethod")
+	e.noMethod = handlerFunc
+	e.noMethodMiddlewares = middlewares
+	e.noMethodChain = e.buildChain(e.noMethod, e.noMethodMiddlewares)
+}
+
+// defaultOptions 调用前已经设置好Allow响应头
+func defaultOptions(ctx *Context) {
+	ctx.W.WriteHeader(http.StatusNoContent)
+	ctx.StatusCode = http.StatusNoContent
+}
+
+func defaultNoRoute(ctx *Context) {
+	ctx.String(http.StatusNotFound, "%s NOT FOUND", ctx.R.URL.Path)
+}
+
+func defaultNoMethod(ctx *Context) {
+	ctx.String(http.StatusMethodNotAllowed, "%s %s NOT ALLOWD", ctx.R.RequestURI, ctx.R.Method)
+}
+
+// headResponseWriter HEAD请求只返回响应头 写入的响应体直接丢弃
+type headResponseWriter struct {
+	ResponseWriter
+}
+
+func (w *headResponseWriter) Write(b []byte) (int, error) {
+	if !w.Written() {
+		w.WriteHeader(http.StatusOK)
+	}
+	return len(b), nil
+}
+
+func (w *headResponseWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
 }
 
 func (e *Engine) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	context := e.pool.Get().(*Context)
 	context.Logger = e.Logger
-	context.W = w
-	context.R = r
+	if cap(context.params) < e.maxParams {
+		context.params = make(Params, 0, e.maxParams)
+	}
+	context.reset(w, r)
 	e.HTTPRequestHandler(context, w, r)
+	// 放回池中之前清空 不让请求的数据在池中多留
+	context.reset(nil, nil)
 	e.pool.Put(context)
 }
 
-func (e *Engine) Run() {
-
-	http.Handle("/", e)
-	err := http.ListenAndServe(":8080", nil)
-	if err != nil {
-		return
-	}
-}
-
 func (e *Engine) MiddlewareHandle(middlewareFunc ...MiddlewareFunc) {
 	e.Middleware = append(e.Middleware, middlewareFunc...)
+	e.rebuildChains()
+}
+
+// Use 添加 Next/Abort 风格的引擎中间件
+func (e *Engine) Use(handlers ...HandlerFunc) {
+	e.handlers = append(e.handlers, handlers...)
+	e.rebuildChains()
 }
 
 func (e *Engine) RegisterErrorHandler(handler ErrorHandler) {
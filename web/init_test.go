@@ -0,0 +1,485 @@
+package web
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func mustPanic(t *testing.T, name string, f func()) {
+	t.Helper()
+	defer func() {
+		if err := recover(); err == nil {
+			t.Errorf("%s: want panic", name)
+		} else {
+			t.Logf("%s: %v", name, err)
+		}
+	}()
+	f()
+}
+
+func TestRouteConflict(t *testing.T) {
+	engine := New()
+	g := engine.Group("user")
+	h := func(ctx *Context) {}
+	g.Get("/:id", h)
+	g.Post("/:id", h)
+	mustPanic(t, "duplicate", func() { g.Get("/:id", h) })
+	mustPanic(t, "duplicate without slash", func() { g.Post(":id", h) })
+	mustPanic(t, "param name", func() { g.Put("/:name", h) })
+	mustPanic(t, "catch-all", func() { g.Get("/files/**/info", h) })
+	// 同一前缀的分组共用一棵路由树
+	mustPanic(t, "duplicate across groups", func() { engine.Group("/user").Get("/:id", h) })
+	mustPanic(t, "duplicate full path", func() { engine.Group("/").Post("/user/:id", h) })
+}
+
+func recordMiddleware(trace *[]string, name string) MiddlewareFunc {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(ctx *Context) {
+			*trace = append(*trace, name)
+			next(ctx)
+		}
+	}
+}
+
+func TestNestedGroup(t *testing.T) {
+	engine := New()
+	var trace []string
+	api := engine.Group("/api")
+	api.MiddlewareHandle(recordMiddleware(&trace, "api"))
+	v1 := api.Group("v1")
+	v1.MiddlewareHandle(recordMiddleware(&trace, "v1"))
+	users := v1.Group("/users/")
+	users.Get("/:id", func(ctx *Context) {
+		ctx.String(http.StatusOK, "user %s", ctx.Param("id"))
+	}, recordMiddleware(&trace, "route"))
+	api.Get("/v1/ping", func(ctx *Context) {
+		ctx.String(http.StatusOK, "pong")
+	})
+	engine.Group("/apis").Get("/", func(ctx *Context) {
+		ctx.String(http.StatusOK, "apis")
+	})
+	// 不同分组之间同样是静态路由优先
+	engine.Group("/items").Get("/:id", func(ctx *Context) {
+		ctx.String(http.StatusOK, "item %s", ctx.Param("id"))
+	})
+	engine.Group("/").Get("/items/new", func(ctx *Context) {
+		ctx.String(http.StatusOK, "new item")
+	})
+
+	tests := []struct {
+		path string
+		code int
+		body string
+	}{
+		{"/api/v1/users/42", http.StatusOK, "user 42"},
+		{"/api/v1/ping", http.StatusOK, "pong"},
+		{"/apis", http.StatusOK, "apis"},
+		{"/apis/", http.StatusMovedPermanently, ""},
+		{"/items/new", http.StatusOK, "new item"},
+		{"/items/7", http.StatusOK, "item 7"},
+		{"/api/v1/users", http.StatusNotFound, ""},
+		{"/api/v2/users/42", http.StatusNotFound, ""},
+	}
+	for _, test := range tests {
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, test.path, nil))
+		if w.Code != test.code {
+			t.Errorf("%s: code %d, want %d", test.path, w.Code, test.code)
+		}
+		if test.body != "" && w.Body.String() != test.body {
+			t.Errorf("%s: body %q, want %q", test.path, w.Body.String(), test.body)
+		}
+	}
+	if got := strings.Join(trace, ","); got != "api,v1,route,api" {
+		t.Errorf("middleware trace %s", got)
+	}
+}
+
+func TestMethods(t *testing.T) {
+	engine := New()
+	g := engine.Group("/")
+	method := func(ctx *Context) {
+		ctx.W.Header().Set("X-Method", ctx.R.Method)
+		ctx.String(http.StatusOK, "method %s", ctx.R.Method)
+	}
+	g.Any("/any", method)
+	g.Match([]string{http.MethodGet, http.MethodPatch}, "/match", method)
+	g.Handle("PROPFIND", "/dav", method)
+	g.Get("/get", method)
+	mustPanic(t, "lower case method", func() { g.Handle("propfind", "/dav2", method) })
+
+	tests := []struct {
+		method, path string
+		code         int
+		body         string
+	}{
+		{http.MethodTrace, "/any", http.StatusOK, "method TRACE"},
+		{http.MethodConnect, "/any", http.StatusOK, "method CONNECT"},
+		{http.MethodPatch, "/match", http.StatusOK, "method PATCH"},
+		{http.MethodPost, "/match", http.StatusMethodNotAllowed, ""},
+		{"PROPFIND", "/dav", http.StatusOK, "method PROPFIND"},
+		{http.MethodHead, "/get", http.StatusOK, ""},
+		{http.MethodHead, "/dav", http.StatusMethodNotAllowed, ""},
+	}
+	for _, test := range tests {
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, httptest.NewRequest(test.method, test.path, nil))
+		if w.Code != test.code {
+			t.Errorf("%s %s: code %d, want %d", test.method, test.path, w.Code, test.code)
+		}
+		if test.code == http.StatusOK && w.Body.String() != test.body {
+			t.Errorf("%s %s: body %q, want %q", test.method, test.path, w.Body.String(), test.body)
+		}
+	}
+
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, httptest.NewRequest(http.MethodHead, "/get", nil))
+	if w.Header().Get("X-Method") != http.MethodHead {
+		t.Errorf("HEAD fallback lost headers: %v", w.Header())
+	}
+}
+
+func TestAllowHeader(t *testing.T) {
+	engine := New()
+	// 自动应答的OPTIONS同样经过引擎中间件
+	engine.Use(func(ctx *Context) {
+		ctx.W.Header().Set("Access-Control-Allow-Origin", "*")
+	})
+	g := engine.Group("/")
+	h := func(ctx *Context) {}
+	g.Get("/users", h)
+	g.Post("/users", h)
+	g.Options("/custom", func(ctx *Context) {
+		ctx.String(http.StatusOK, "custom")
+	})
+
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, httptest.NewRequest(http.MethodDelete, "/users", nil))
+	if w.Code != http.StatusMethodNotAllowed || w.Header().Get("Allow") != "GET, HEAD, OPTIONS, POST" {
+		t.Errorf("405: code %d, Allow %q", w.Code, w.Header().Get("Allow"))
+	}
+
+	w = httptest.NewRecorder()
+	engine.ServeHTTP(w, httptest.NewRequest(http.MethodOptions, "/users", nil))
+	if w.Code != http.StatusNoContent || w.Header().Get("Allow") != "GET, HEAD, OPTIONS, POST" {
+		t.Errorf("OPTIONS: code %d, Allow %q", w.Code, w.Header().Get("Allow"))
+	}
+	if w.Header().Get("Access-Control-Allow-Origin") != "*" {
+		t.Error("OPTIONS: engine middleware not applied")
+	}
+
+	w = httptest.NewRecorder()
+	engine.ServeHTTP(w, httptest.NewRequest(http.MethodOptions, "/custom", nil))
+	if w.Body.String() != "custom" {
+		t.Errorf("custom OPTIONS handler not used: %q", w.Body.String())
+	}
+
+	engine.HandleMethodNotAllowed = false
+	engine.HandleOPTIONS = false
+	for _, method := range []string{http.MethodDelete, http.MethodOptions} {
+		w = httptest.NewRecorder()
+		engine.ServeHTTP(w, httptest.NewRequest(method, "/users", nil))
+		if w.Code != http.StatusNotFound {
+			t.Errorf("%s with options disabled: code %d", method, w.Code)
+		}
+	}
+}
+
+func TestNoRouteNoMethod(t *testing.T) {
+	engine := New()
+	var trace []string
+	engine.MiddlewareHandle(recordMiddleware(&trace, "engine"))
+	engine.Group("/").Get("/users", func(ctx *Context) {})
+	engine.NoRoute(func(ctx *Context) {
+		ctx.JsonTemplate(http.StatusNotFound, map[string]string{"path": ctx.R.URL.Path})
+	}, recordMiddleware(&trace, "noRoute"))
+	engine.NoMethod(func(ctx *Context) {
+		ctx.JsonTemplate(http.StatusMethodNotAllowed, map[string]string{"allow": ctx.W.Header().Get("Allow")})
+	})
+
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/missing", nil))
+	if w.Code != http.StatusNotFound || w.Body.String() != `{"path":"/missing"}` {
+		t.Errorf("404: code %d, body %s", w.Code, w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	engine.ServeHTTP(w, httptest.NewRequest(http.MethodDelete, "/users", nil))
+	if w.Code != http.StatusMethodNotAllowed || w.Body.String() != `{"allow":"GET, HEAD, OPTIONS"}` {
+		t.Errorf("405: code %d, body %s", w.Code, w.Body.String())
+	}
+
+	if got := strings.Join(trace, ","); got != "engine,noRoute,engine" {
+		t.Errorf("middleware trace %s", got)
+	}
+}
+
+func TestRedirectFixedPath(t *testing.T) {
+	engine := New()
+	h := func(ctx *Context) {}
+	g := engine.Group("/")
+	g.Get("/users", h)
+	g.Post("/users/:id/", h)
+	engine.Group("/Admin").Get("/Panel", h)
+
+	tests := []struct {
+		method, path string
+		code         int
+		location     string
+	}{
+		{http.MethodGet, "/users/", http.StatusMovedPermanently, "/users"},
+		{http.MethodGet, "/users/?page=2", http.StatusMovedPermanently, "/users?page=2"},
+		{http.MethodPost, "/users/42", http.StatusPermanentRedirect, "/users/42/"},
+		{http.MethodGet, "//users/./", http.StatusNotFound, ""},
+		{http.MethodGet, "/ADMIN/panel", http.StatusNotFound, ""},
+	}
+	check := func() {
+		for _, test := range tests {
+			w := httptest.NewRecorder()
+			engine.ServeHTTP(w, httptest.NewRequest(test.method, test.path, nil))
+			if w.Code != test.code || w.Header().Get("Location") != test.location {
+				t.Errorf("%s %s: code %d location %q, want %d %q", test.method, test.path,
+					w.Code, w.Header().Get("Location"), test.code, test.location)
+			}
+		}
+	}
+	check()
+
+	engine.RedirectCleanPath = true
+	engine.RedirectCaseInsensitive = true
+	tests[3].code, tests[3].location = http.StatusMovedPermanently, "/users"
+	tests[4].code, tests[4].location = http.StatusMovedPermanently, "/Admin/Panel"
+	tests = append(tests, struct {
+		method, path string
+		code         int
+		location     string
+	}{http.MethodGet, "/users/../Users/", http.StatusMovedPermanently, "/users"})
+	check()
+
+	// 重定向地址不能被浏览器当作其他域名
+	engine = New()
+	engine.Group("/").Get("/:a", h)
+	tests = tests[:0]
+	tests = append(tests, []struct {
+		method, path string
+		code         int
+		location     string
+	}{
+		{http.MethodGet, "/\\evil.com/", http.StatusMovedPermanently, "/%5Cevil.com"},
+		{http.MethodGet, "/a%20b/", http.StatusMovedPermanently, "/a%20b"},
+	}...)
+	check()
+}
+
+func TestParamConstraint(t *testing.T) {
+	engine := New()
+	g := engine.Group("/posts")
+	g.Get("/:id<int>", func(ctx *Context) {
+		id, _ := ctx.ParamInt("id")
+		ctx.String(http.StatusOK, "%T %d", ctx.ParamValue("id"), id)
+	})
+
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/posts/42", nil))
+	if w.Body.String() != "int64 42" {
+		t.Errorf("body %q", w.Body.String())
+	}
+	w = httptest.NewRecorder()
+	engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/posts/hello", nil))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("constraint mismatch: code %d", w.Code)
+	}
+}
+
+func TestHostRouting(t *testing.T) {
+	engine := New()
+	hostHandler := func(name string) HandlerFunc {
+		return func(ctx *Context) {
+			ctx.String(http.StatusOK, "%s %s %s", name, ctx.Param("tenant"), ctx.Param("id"))
+		}
+	}
+	// 先注册的 * 不会抢走精确的域名
+	engine.Host("*.example.com").Get("/users/:id", hostHandler("wild"))
+	engine.Host("api.example.com").Group("/v1").Get("/users/:id", hostHandler("api"))
+	engine.Host(":tenant.example.com").Get("/users/:id", hostHandler("tenant"))
+	engine.Group("/").Get("/users/:id", hostHandler("default"))
+	mustPanic(t, "invalid host", func() { engine.Host("a..b") })
+
+	tests := []struct {
+		host, path string
+		code       int
+		body       string
+	}{
+		{"api.example.com", "/v1/users/1", http.StatusOK, "api  1"},
+		{"API.example.com:8080", "/v1/users/1", http.StatusOK, "api  1"},
+		{"api.example.com", "/users/1", http.StatusNotFound, ""},
+		{"acme.example.com", "/users/2", http.StatusOK, "tenant acme 2"},
+		{"a.b.example.com", "/users/3", http.StatusOK, "default  3"},
+		{"api.example.com", "/users/5", http.StatusNotFound, ""},
+		{"localhost", "/users/4", http.StatusOK, "default  4"},
+	}
+	for _, test := range tests {
+		r := httptest.NewRequest(http.MethodGet, test.path, nil)
+		r.Host = test.host
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, r)
+		if w.Code != test.code || test.code == http.StatusOK && w.Body.String() != test.body {
+			t.Errorf("%s%s: code %d body %q, want %d %q", test.host, test.path, w.Code, w.Body.String(), test.code, test.body)
+		}
+	}
+}
+
+func TestLateMiddleware(t *testing.T) {
+	engine := New()
+	var trace []string
+	api := engine.Group("/api")
+	v1 := api.Group("/v1")
+	v1.Get("/ping", func(ctx *Context) {})
+	// 路由注册之后再添加的中间件同样生效
+	api.MiddlewareHandle(recordMiddleware(&trace, "api"))
+	v1.MiddlewareHandle(recordMiddleware(&trace, "v1"))
+
+	engine.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/api/v1/ping", nil))
+	if got := strings.Join(trace, ","); got != "api,v1" {
+		t.Errorf("middleware trace %s", got)
+	}
+}
+
+func TestGlobalMiddleware(t *testing.T) {
+	engine := New()
+	var trace []string
+	api := engine.Group("/api")
+	api.Get("/users", func(ctx *Context) {})
+	api.Get("/health", func(ctx *Context) {}).ExcludeGlobalMiddleware()
+	internal := engine.Group("/internal").ExcludeGlobalMiddleware()
+	internal.Group("/debug").Get("/vars", func(ctx *Context) {})
+	// 分组和路由注册之后再添加的引擎中间件同样生效
+	engine.MiddlewareHandle(recordMiddleware(&trace, "engine"))
+	api.MiddlewareHandle(recordMiddleware(&trace, "api"))
+
+	tests := []struct {
+		method string
+		path   string
+		trace  string
+	}{
+		{http.MethodGet, "/api/users", "engine,api"},
+		{http.MethodGet, "/api/health", "api"},
+		{http.MethodGet, "/internal/debug/vars", ""},
+		{http.MethodGet, "/missing", "engine"},
+		{http.MethodPost, "/api/users", "engine"},
+	}
+	for _, tt := range tests {
+		trace = nil
+		engine.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(tt.method, tt.path, nil))
+		if got := strings.Join(trace, ","); got != tt.trace {
+			t.Errorf("%s %s trace %s, want %s", tt.method, tt.path, got, tt.trace)
+		}
+	}
+}
+
+func TestNextAbort(t *testing.T) {
+	engine := New()
+	var trace []string
+	api := engine.Group("/api")
+	api.Use(func(ctx *Context) {
+		trace = append(trace, "before")
+		ctx.Next()
+		trace = append(trace, "after")
+	})
+	api.MiddlewareHandle(recordMiddleware(&trace, "decorator"))
+	auth := func(ctx *Context) {
+		if ctx.R.Header.Get("Token") == "" {
+			ctx.AbortWithStatus(http.StatusUnauthorized)
+		}
+	}
+	api.Get("/users", func(ctx *Context) {
+		// 在辅助函数里中断 后面的处理函数不会执行
+		auth(ctx)
+		if ctx.IsAborted() {
+			return
+		}
+		trace = append(trace, "handler")
+	})
+	// 不调用next的MiddlewareFunc会中断处理链
+	api.Get("/closed", func(ctx *Context) {
+		trace = append(trace, "handler")
+	}, func(next HandlerFunc) HandlerFunc {
+		return func(ctx *Context) {
+			ctx.AbortWithStatus(http.StatusForbidden)
+		}
+	})
+
+	tests := []struct {
+		path  string
+		token string
+		code  int
+		trace string
+	}{
+		{"/api/users", "", http.StatusUnauthorized, "decorator,before,after"},
+		{"/api/users", "x", http.StatusOK, "decorator,before,handler,after"},
+		{"/api/closed", "x", http.StatusForbidden, "decorator,before,after"},
+	}
+	for _, tt := range tests {
+		trace = nil
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, tt.path, nil)
+		r.Header.Set("Token", tt.token)
+		engine.ServeHTTP(w, r)
+		if w.Code != tt.code {
+			t.Errorf("%s token %q code %d, want %d", tt.path, tt.token, w.Code, tt.code)
+		}
+		if got := strings.Join(trace, ","); got != tt.trace {
+			t.Errorf("%s token %q trace %s, want %s", tt.path, tt.token, got, tt.trace)
+		}
+	}
+}
+
+type benchResponseWriter struct {
+	header http.Header
+}
+
+func (w *benchResponseWriter) Header() http.Header {
+	return w.header
+}
+
+func (w *benchResponseWriter) Write(b []byte) (int, error) {
+	return len(b), nil
+}
+
+func (w *benchResponseWriter) WriteHeader(int) {}
+
+func nopMiddleware(next HandlerFunc) HandlerFunc {
+	return func(ctx *Context) {
+		next(ctx)
+	}
+}
+
+func benchmarkServeHTTP(b *testing.B, path string) {
+	engine := New()
+	// 去掉会打印日志的默认中间件 只统计路由本身
+	engine.Middleware = nil
+	engine.rebuildChains()
+	g := engine.Group("/api")
+	g.MiddlewareHandle(nopMiddleware, nopMiddleware)
+	h := func(ctx *Context) {}
+	for _, route := range benchRoutes {
+		g.Get(route, h, nopMiddleware)
+	}
+	r := httptest.NewRequest(http.MethodGet, path, nil)
+	w := &benchResponseWriter{header: make(http.Header)}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		engine.ServeHTTP(w, r)
+	}
+}
+
+func BenchmarkServeHTTPStatic(b *testing.B) {
+	benchmarkServeHTTP(b, "/api/user/logout")
+}
+
+func BenchmarkServeHTTPParams(b *testing.B) {
+	benchmarkServeHTTP(b, "/api/user/42/repos/web/issues/7")
+}
@@ -0,0 +1,126 @@
+package web
+
+import (
+	"fmt"
+	"time"
+)
+
+// Set 保存只在当前请求内有效的数据 用于中间件向处理函数传值
+func (c *Context) Set(key string, value any) {
+	c.keysMutex.Lock()
+	defer c.keysMutex.Unlock()
+	if c.Keys == nil {
+		c.Keys = make(map[string]any)
+	}
+	c.Keys[key] = value
+}
+
+func (c *Context) Get(key string) (value any, exists bool) {
+	c.keysMutex.RLock()
+	defer c.keysMutex.RUnlock()
+	value, exists = c.Keys[key]
+	return
+}
+
+// MustGet key不存在时panic
+func (c *Context) MustGet(key string) any {
+	if value, exists := c.Get(key); exists {
+		return value
+	}
+	panic(fmt.Sprintf("web: key %s does not exist", key))
+}
+
+// Value 按类型取出Set保存的数据 key不存在或者类型不符时返回零值和false
+func Value[T any](c *Context, key string) (T, bool) {
+	value, exists := c.Get(key)
+	if !exists {
+		var zero T
+		return zero, false
+	}
+	t, ok := value.(T)
+	return t, ok
+}
+
+func (c *Context) GetString(key string) string {
+	s, _ := Value[string](c, key)
+	return s
+}
+
+func (c *Context) GetBool(key string) bool {
+	b, _ := Value[bool](c, key)
+	return b
+}
+
+func (c *Context) GetInt(key string) int {
+	i, _ := Value[int](c, key)
+	return i
+}
+
+func (c *Context) GetInt64(key string) int64 {
+	i, _ := Value[int64](c, key)
+	return i
+}
+
+func (c *Context) GetFloat64(key string) float64 {
+	f, _ := Value[float64](c, key)
+	return f
+}
+
+func (c *Context) GetTime(key string) time.Time {
+	t, _ := Value[time.Time](c, key)
+	return t
+}
+
+func (c *Context) GetDuration(key string) time.Duration {
+	d, _ := Value[time.Duration](c, key)
+	return d
+}
+
+func (c *Context) GetStringSlice(key string) []string {
+	s, _ := Value[[]string](c, key)
+	return s
+}
+
+func (c *Context) GetStringMap(key string) map[string]any {
+	m, _ := Value[map[string]any](c, key)
+	return m
+}
+
+// 以下方法让Context实现context.Context 可以直接传给数据库等客户端
+// 截止时间以及取消信号来自请求本身
+// 只能在处理函数返回之前使用 之后Context会被下一个请求复用
+// 需要交给协程或者在请求结束后继续使用时 传 ctx.Copy() 或者 ctx.R.Context()
+
+func (c *Context) Deadline() (deadline time.Time, ok bool) {
+	if c.R == nil {
+		return
+	}
+	return c.R.Context().Deadline()
+}
+
+func (c *Context) Done() <-chan struct{} {
+	if c.R == nil {
+		return nil
+	}
+	return c.R.Context().Done()
+}
+
+func (c *Context) Err() error {
+	if c.R == nil {
+		return nil
+	}
+	return c.R.Context().Err()
+}
+
+// Value 字符串类型的key先查找Set保存的数据 找不到再交给请求的context
+func (c *Context) Value(key any) any {
+	if k, ok := key.(string); ok {
+		if value, exists := c.Get(k); exists {
+			return value
+		}
+	}
+	if c.R == nil {
+		return nil
+	}
+	return c.R.Context().Value(key)
+}
@@ -0,0 +1,80 @@
+package web
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+)
+
+type ctxKey struct{}
+
+func TestContextKeys(t *testing.T) {
+	engine := New()
+	engine.Use(func(ctx *Context) {
+		ctx.Set("user", "alice")
+		ctx.Set("tenant", 42)
+		ctx.Next()
+	})
+	var leaked bool
+	engine.Group("").Get("/keys", func(ctx *Context) {
+		if _, ok := ctx.Get("fresh"); ok {
+			leaked = true
+		}
+		ctx.Set("fresh", true)
+		if got := ctx.GetString("user"); got != "alice" {
+			t.Errorf("GetString(user) = %q", got)
+		}
+		if got := ctx.GetInt("tenant"); got != 42 {
+			t.Errorf("GetInt(tenant) = %d", got)
+		}
+		if got := ctx.GetString("tenant"); got != "" {
+			t.Errorf("GetString(tenant) = %q, want empty", got)
+		}
+		if _, ok := Value[string](ctx, "tenant"); ok {
+			t.Error("Value[string](tenant) should fail on type mismatch")
+		}
+		// Context 可以直接当作 context.Context 使用
+		var c context.Context = ctx
+		if got := c.Value("user"); got != "alice" {
+			t.Errorf("Value(user) = %v", got)
+		}
+		if got := c.Value(ctxKey{}); got != "from request" {
+			t.Errorf("Value(ctxKey) = %v", got)
+		}
+		mustPanic(t, "MustGet missing", func() { ctx.MustGet("missing") })
+	})
+
+	for i := 0; i < 2; i++ {
+		r := httptest.NewRequest(http.MethodGet, "/keys", nil)
+		r = r.WithContext(context.WithValue(r.Context(), ctxKey{}, "from request"))
+		engine.ServeHTTP(httptest.NewRecorder(), r)
+	}
+	if leaked {
+		t.Error("keys leaked between requests")
+	}
+}
+
+func TestContextCopy(t *testing.T) {
+	engine := New()
+	engine.Middleware = nil
+	var copies []*Context
+	engine.Group("").Get("/users/:id", func(ctx *Context) {
+		ctx.Set("user", ctx.Param("id"))
+		copies = append(copies, ctx.Copy())
+	})
+	for _, path := range []string{"/users/1", "/users/2"} {
+		engine.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+	// 请求结束后原来的Context已经被复用 副本不受影响
+	for i, cp := range copies {
+		want := strconv.Itoa(i + 1)
+		if cp.GetString("user") != want || cp.Param("id") != want || cp.R.URL.Path != "/users/"+want {
+			t.Errorf("copy %d: user %q id %q path %q", i, cp.GetString("user"), cp.Param("id"), cp.R.URL.Path)
+		}
+		if cp.Value("user") != want {
+			t.Errorf("copy %d: context value %v", i, cp.Value("user"))
+		}
+	}
+}
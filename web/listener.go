@@ -0,0 +1,116 @@
+package web
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// listenFdsStart systemd 等传入的第一个文件描述符 0 1 2 为标准输入输出
+const listenFdsStart = 3
+
+// RunListener 在已有的 net.Listener 上启动服务
+func (e *Engine) RunListener(l net.Listener) error {
+	srv := &http.Server{Handler: e}
+	return e.serve(srv, func() error {
+		return srv.Serve(l)
+	})
+}
+
+// RunUnix 监听Unix域套接字 用于本机的反向代理 perm 为套接字文件的权限
+// 上次异常退出留下的套接字文件会先删除
+func (e *Engine) RunUnix(path string, perm os.FileMode) error {
+	if stat, err := os.Lstat(path); err == nil {
+		if stat.Mode()&os.ModeSocket == 0 {
+			return fmt.Errorf("web: %s exists and is not a socket", path)
+		}
+		if err := os.Remove(path); err != nil {
+			return err
+		}
+	}
+	l, err := net.Listen("unix", path)
+	if err != nil {
+		return err
+	}
+	// 关闭时自动删除套接字文件
+	defer l.Close()
+	if err := os.Chmod(path, perm); err != nil {
+		return err
+	}
+	return e.RunListener(l)
+}
+
+// RunFd 在继承来的文件描述符上启动服务 用于新进程接管旧进程的监听
+func (e *Engine) RunFd(fd int) error {
+	l, err := fileListener(uintptr(fd), "fd"+strconv.Itoa(fd))
+	if err != nil {
+		return err
+	}
+	return e.RunListener(l)
+}
+
+// ListenersFromEnv 按 systemd 的套接字激活协议读取 LISTEN_FDS 传入的监听
+// LISTEN_PID 不是当前进程时返回空 读取后清除这些环境变量 避免子进程重复使用
+func ListenersFromEnv() ([]net.Listener, error) {
+	defer func() {
+		os.Unsetenv("LISTEN_PID")
+		os.Unsetenv("LISTEN_FDS")
+		os.Unsetenv("LISTEN_FDNAMES")
+	}()
+	if pid := os.Getenv("LISTEN_PID"); pid != "" && pid != strconv.Itoa(os.Getpid()) {
+		return nil, nil
+	}
+	count := os.Getenv("LISTEN_FDS")
+	if count == "" {
+		return nil, nil
+	}
+	n, err := strconv.Atoi(count)
+	if err != nil || n < 0 {
+		return nil, fmt.Errorf("web: invalid LISTEN_FDS %q", count)
+	}
+	names := strings.Split(os.Getenv("LISTEN_FDNAMES"), ":")
+	listeners := make([]net.Listener, 0, n)
+	for i := 0; i < n; i++ {
+		name := "LISTEN_FD_" + strconv.Itoa(listenFdsStart+i)
+		if i < len(names) && names[i] != "" {
+			name = names[i]
+		}
+		l, err := fileListener(uintptr(listenFdsStart+i), name)
+		if err != nil {
+			for _, l := range listeners {
+				l.Close()
+			}
+			return nil, err
+		}
+		listeners = append(listeners, l)
+	}
+	return listeners, nil
+}
+
+// ListenerFile 返回监听对应的文件 放到 exec.Cmd.ExtraFiles 中传给新进程
+// 新进程中第 i 个 ExtraFiles 的描述符为 3+i
+func ListenerFile(l net.Listener) (*os.File, error) {
+	f, ok := l.(interface{ File() (*os.File, error) })
+	if !ok {
+		return nil, errors.New("web: listener does not support File")
+	}
+	return f.File()
+}
+
+// fileListener net.FileListener 会复制一份描述符 原来的文件可以直接关闭
+func fileListener(fd uintptr, name string) (net.Listener, error) {
+	f := os.NewFile(fd, name)
+	if f == nil {
+		return nil, fmt.Errorf("web: invalid file descriptor %d", fd)
+	}
+	defer f.Close()
+	l, err := net.FileListener(f)
+	if err != nil {
+		return nil, fmt.Errorf("web: %s is not a listener: %w", name, err)
+	}
+	return l, nil
+}
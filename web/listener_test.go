@@ -0,0 +1,56 @@
+package web
+
+import (
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func newPingEngine() *Engine {
+	engine := New()
+	engine.Middleware = nil
+	engine.Group("").Get("/ping", func(ctx *Context) {
+		ctx.String(http.StatusOK, "pong")
+	})
+	return engine
+}
+
+// getPing 服务启动需要一点时间 失败时重试
+func getPing(t *testing.T, client *http.Client, url string) {
+	t.Helper()
+	var resp *http.Response
+	var err error
+	for i := 0; i < 50; i++ {
+		if resp, err = client.Get(url); err == nil {
+			break
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if body, _ := io.ReadAll(resp.Body); string(body) != "pong" {
+		t.Errorf("body %q", body)
+	}
+}
+
+func TestListenersFromEnv(t *testing.T) {
+	t.Setenv("LISTEN_PID", strconv.Itoa(os.Getpid()+1))
+	t.Setenv("LISTEN_FDS", "1")
+	if listeners, err := ListenersFromEnv(); listeners != nil || err != nil {
+		t.Errorf("other process: %v %v", listeners, err)
+	}
+	if os.Getenv("LISTEN_FDS") != "" {
+		t.Error("LISTEN_FDS not cleared")
+	}
+
+	t.Setenv("LISTEN_PID", strconv.Itoa(os.Getpid()))
+	t.Setenv("LISTEN_FDS", "x")
+	if _, err := ListenersFromEnv(); err == nil {
+		t.Error("invalid LISTEN_FDS should fail")
+	}
+}
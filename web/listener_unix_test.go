@@ -0,0 +1,73 @@
+//go:build unix
+
+package web
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+)
+
+func TestRunUnix(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "web.sock")
+	// 上次留下的套接字文件
+	stale, err := net.Listen("unix", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stale.(*net.UnixListener).SetUnlinkOnClose(false)
+	stale.Close()
+
+	engine := newPingEngine()
+	runErr := make(chan error, 1)
+	go func() { runErr <- engine.RunUnix(path, 0600) }()
+
+	client := &http.Client{Transport: &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			return (&net.Dialer{}).DialContext(ctx, "unix", path)
+		},
+	}}
+	getPing(t, client, "http://unix/ping")
+	if stat, err := os.Stat(path); err != nil || stat.Mode().Perm() != 0600 {
+		t.Errorf("socket stat %v %v", stat, err)
+	}
+	engine.Shutdown(context.Background())
+	if err := <-runErr; err != nil {
+		t.Errorf("RunUnix error %v", err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("socket file not removed: %v", err)
+	}
+}
+
+func TestRunFd(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f, err := ListenerFile(l)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 模拟从父进程继承的描述符 交给RunFd之后由它负责关闭
+	fd, err := syscall.Dup(int(f.Fd()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+	addr := l.Addr().String()
+	l.Close()
+
+	engine := newPingEngine()
+	runErr := make(chan error, 1)
+	go func() { runErr <- engine.RunFd(fd) }()
+	getPing(t, http.DefaultClient, "http://"+addr+"/ping")
+	engine.Shutdown(context.Background())
+	if err := <-runErr; err != nil {
+		t.Errorf("RunFd error %v", err)
+	}
+}
@@ -39,13 +39,15 @@ type LoggerConfig struct {
 type LoggerFormatter func(params *LogFormatterParams) string
 
 type LogFormatterParams struct {
-	Request      *http.Request
-	TimeStamp    time.Time
-	StatusCode   int
-	Latency      time.Duration
-	ClientIP     net.IP
-	Method       string
-	Path         string
+	Request    *http.Request
+	TimeStamp  time.Time
+	StatusCode int
+	Latency    time.Duration
+	ClientIP   net.IP
+	Method     string
+	Path       string
+	// Proto 协商后的协议版本 如 HTTP/1.1 HTTP/2.0
+	Proto        string
 	DisplayColor bool
 }
 
@@ -71,17 +73,18 @@ var defaultLogFormatter = func(params *LogFormatterParams) string {
 	}
 	// 不开启颜色显示 用于向文件输出日志
 	if params.DisplayColor == false {
-		return fmt.Sprintf("[msgo] %v | %3d | %13v | %15s |%-7s %#v",
+		return fmt.Sprintf("[msgo] %v | %3d | %13v | %15s | %-8s |%-7s %#v",
 			params.TimeStamp.Format("2006/01/02 - 15:04:05"),
 			params.StatusCode,
-			params.Latency, params.ClientIP, params.Method, params.Path,
+			params.Latency, params.ClientIP, params.Proto, params.Method, params.Path,
 		)
 	}
-	return fmt.Sprintf("%s [otto] %s |%s %v %s| %s %3d %s |%s %13v %s| %15s  |%s %-7s %s %s %#v %s",
+	return fmt.Sprintf("%s [otto] %s |%s %v %s| %s %3d %s |%s %13v %s| %15s  | %-8s |%s %-7s %s %s %#v %s",
 		yellow, resetColor, blue, params.TimeStamp.Format("2006/01/02 - 15:04:05"), resetColor,
 		statusCodeColor, params.StatusCode, resetColor,
 		red, params.Latency, resetColor,
 		params.ClientIP,
+		params.Proto,
 		magenta, params.Method, resetColor,
 		cyan, params.Path, resetColor,
 	)
@@ -114,7 +117,7 @@ func LoggingWithConfig(conf *LoggerConfig, next HandlerFunc) HandlerFunc {
 		ip, _, _ := net.SplitHostPort(strings.TrimSpace(ctx.R.RemoteAddr))
 		clientIP := net.ParseIP(ip)
 		method := ctx.R.Method
-		statusCode := ctx.StatusCode
+		statusCode := ctx.W.Status()
 
 		if raw != "" {
 			path = path + "?" + raw
@@ -125,6 +128,7 @@ func LoggingWithConfig(conf *LoggerConfig, next HandlerFunc) HandlerFunc {
 		param.Latency = latency
 		param.StatusCode = statusCode
 		param.Method = method
+		param.Proto = ctx.R.Proto
 		param.Path = path
 		param.DisplayColor = true
 		fmt.Fprint(out, formatter(param))
@@ -1,6 +1,7 @@
 package log
 
 import (
+	"errors"
 	"fmt"
 	"github.com/MucOtto/web/internel/mystrings"
 	"io"
@@ -201,3 +202,19 @@ func fileWriter(name string) io.Writer {
 	}
 	return w
 }
+
+// Close 关闭SetFilePath打开的日志文件 标准输出不受影响
+func (l *Logger) Close() error {
+	var errs []error
+	for _, out := range l.Outs {
+		if out.Out == os.Stdout || out.Out == os.Stderr {
+			continue
+		}
+		if c, ok := out.Out.(io.Closer); ok {
+			if err := c.Close(); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,167 @@
+package web
+
+import (
+	"errors"
+	"github.com/MucOtto/web/render"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+const (
+	MIMEJSON  = "application/json"
+	MIMEXML   = "application/xml"
+	MIMEXML2  = "text/xml"
+	MIMEHTML  = "text/html"
+	MIMEPlain = "text/plain"
+)
+
+// ErrNotAcceptable 客户端Accept中的格式服务端都不提供
+var ErrNotAcceptable = errors.New("web: none of the offered formats is acceptable")
+
+// Negotiation 各个格式对应的数据 为nil的格式不参与协商
+// Data 作为没有单独设置数据的格式的默认值 此时 Offered 指定提供哪些格式
+type Negotiation struct {
+	Offered  []string
+	JSON     any
+	XML      any
+	HTML     any
+	HTMLName string
+	Text     any
+	Data     any
+}
+
+// offered 按服务端的偏好顺序列出可以提供的格式
+// 没有模板时不提供HTML 协商落到下一个可以接受的格式或406
+func (n *Negotiation) offered(canHTML bool) []string {
+	canHTML = canHTML && n.HTMLName != ""
+	if len(n.Offered) > 0 {
+		if canHTML {
+			return n.Offered
+		}
+		offered := make([]string, 0, len(n.Offered))
+		for _, format := range n.Offered {
+			if format != MIMEHTML {
+				offered = append(offered, format)
+			}
+		}
+		return offered
+	}
+	var offered []string
+	if n.JSON != nil {
+		offered = append(offered, MIMEJSON)
+	}
+	if n.XML != nil {
+		offered = append(offered, MIMEXML, MIMEXML2)
+	}
+	if n.HTML != nil && canHTML {
+		offered = append(offered, MIMEHTML)
+	}
+	if n.Text != nil {
+		offered = append(offered, MIMEPlain)
+	}
+	return offered
+}
+
+func (n *Negotiation) data(format any) any {
+	if format != nil {
+		return format
+	}
+	return n.Data
+}
+
+// Negotiate 按请求的Accept选择格式返回 都不能接受时返回406和ErrNotAcceptable
+//
+//	ctx.Negotiate(http.StatusOK, web.Negotiation{JSON: user, XML: user, Text: user.Name})
+func (c *Context) Negotiate(code int, n Negotiation) error {
+	c.W.Header().Add("Vary", "Accept")
+	canHTML := c.engine != nil && c.engine.HTMLRender != nil
+	switch c.NegotiateFormat(n.offered(canHTML)...) {
+	case MIMEJSON:
+		return c.JsonTemplate(code, n.data(n.JSON))
+	case MIMEXML, MIMEXML2:
+		return c.Render(code, &render.XML{Data: n.data(n.XML)})
+	case MIMEHTML:
+		return c.Render(code, &render.HTML{
+			Template: c.engine.HTMLRender.Template,
+			Name:     n.HTMLName,
+			Data:     n.data(n.HTML),
+		})
+	case MIMEPlain:
+		return c.String(code, "%v", n.data(n.Text))
+	default:
+		c.AbortWithStatus(http.StatusNotAcceptable)
+		return ErrNotAcceptable
+	}
+}
+
+// NegotiateFormat 从offered中选出客户端最想要的格式 没有Accept时返回第一个
+// q值相同时按offered的顺序 都不能接受时返回空字符串
+func (c *Context) NegotiateFormat(offered ...string) string {
+	if len(offered) == 0 {
+		return ""
+	}
+	accept := c.R.Header.Get("Accept")
+	if accept == "" {
+		return offered[0]
+	}
+	ranges := parseAccept(accept)
+	best, bestQ := "", 0.0
+	for _, format := range offered {
+		if q := acceptQuality(ranges, format); q > bestQ {
+			best, bestQ = format, q
+		}
+	}
+	return best
+}
+
+type acceptRange struct {
+	mediaType string
+	q         float64
+}
+
+// parseAccept 解析 text/html;q=0.9, */*;q=0.1 形式的Accept 除q之外的参数忽略
+func parseAccept(accept string) []acceptRange {
+	var ranges []acceptRange
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, params, _ := strings.Cut(part, ";")
+		mediaType = strings.ToLower(strings.TrimSpace(mediaType))
+		if mediaType == "" {
+			continue
+		}
+		q := 1.0
+		for _, param := range strings.Split(params, ";") {
+			key, value, _ := strings.Cut(param, "=")
+			if strings.TrimSpace(key) != "q" {
+				continue
+			}
+			if v, err := strconv.ParseFloat(strings.TrimSpace(value), 64); err == nil && v >= 0 && v <= 1 {
+				q = v
+			}
+		}
+		ranges = append(ranges, acceptRange{mediaType: mediaType, q: q})
+	}
+	return ranges
+}
+
+// acceptQuality 取匹配最具体的那一项的q值 具体程度 type/subtype > type/* > */*
+func acceptQuality(ranges []acceptRange, format string) float64 {
+	format = strings.ToLower(format)
+	mainType, _, _ := strings.Cut(format, "/")
+	q, specificity := 0.0, 0
+	for _, r := range ranges {
+		s := 0
+		switch r.mediaType {
+		case format:
+			s = 3
+		case mainType + "/*":
+			s = 2
+		case "*/*":
+			s = 1
+		}
+		if s > specificity {
+			q, specificity = r.q, s
+		}
+	}
+	return q
+}
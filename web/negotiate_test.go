@@ -0,0 +1,121 @@
+package web
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestNegotiateFormat(t *testing.T) {
+	offered := []string{MIMEJSON, MIMEXML, MIMEHTML}
+	tests := []struct {
+		accept string
+		want   string
+	}{
+		{"", MIMEJSON},
+		{"*/*", MIMEJSON},
+		{"text/html", MIMEHTML},
+		{"application/xml;q=0.9, text/html;q=0.8", MIMEXML},
+		{"text/html, application/*;q=0.5", MIMEHTML},
+		// 更具体的一项优先 application/json 的q值为0
+		{"application/json;q=0, application/*", MIMEXML},
+		{"text/*;q=0.3, */*;q=0.1", MIMEHTML},
+		{"TEXT/HTML; level=1", MIMEHTML},
+		{"image/png", ""},
+		{"text/html;q=0, */*;q=0", ""},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.Header.Set("Accept", tt.accept)
+		ctx := &Context{R: r}
+		if got := ctx.NegotiateFormat(offered...); got != tt.want {
+			t.Errorf("Accept %q: got %q, want %q", tt.accept, got, tt.want)
+		}
+	}
+}
+
+func TestNegotiate(t *testing.T) {
+	type user struct {
+		Name string `json:"name" xml:"name"`
+	}
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "user.html"), []byte(`{{define "user"}}<b>{{.Name}}</b>{{end}}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	engine := New()
+	engine.Middleware = nil
+	engine.LoadTemplate(filepath.Join(dir, "*.html"))
+	engine.Group("").Get("/user", func(ctx *Context) {
+		u := user{Name: "alice"}
+		ctx.Negotiate(http.StatusOK, Negotiation{JSON: u, XML: u, HTML: u, HTMLName: "user", Text: u.Name})
+	})
+
+	tests := []struct {
+		accept      string
+		code        int
+		contentType string
+		body        string
+	}{
+		{"application/json", http.StatusOK, "application/json", `{"name":"alice"}`},
+		{"text/xml", http.StatusOK, "application/xml; charset=utf-8", "<user><name>alice</name></user>"},
+		{"text/html, */*;q=0.1", http.StatusOK, "text/html", "<b>alice</b>"},
+		{"text/plain", http.StatusOK, "text/plain; charset=utf-8", "alice"},
+		{"image/png", http.StatusNotAcceptable, "", ""},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, "/user", nil)
+		r.Header.Set("Accept", tt.accept)
+		engine.ServeHTTP(w, r)
+		if w.Code != tt.code {
+			t.Errorf("Accept %q: code %d, want %d", tt.accept, w.Code, tt.code)
+		}
+		if ct := w.Header().Get("Content-Type"); tt.contentType != "" && !strings.HasPrefix(ct, tt.contentType) {
+			t.Errorf("Accept %q: content type %q, want %q", tt.accept, ct, tt.contentType)
+		}
+		if got := w.Body.String(); got != tt.body {
+			t.Errorf("Accept %q: body %q, want %q", tt.accept, got, tt.body)
+		}
+		if w.Header().Get("Vary") != "Accept" {
+			t.Errorf("Accept %q: missing Vary header", tt.accept)
+		}
+	}
+}
+
+func TestNegotiateWithoutHTML(t *testing.T) {
+	engine := New()
+	engine.Middleware = nil
+	g := engine.Group("")
+	// 没有加载模板
+	g.Get("/user", func(ctx *Context) {
+		ctx.Negotiate(http.StatusOK, Negotiation{JSON: "alice", HTML: "alice", HTMLName: "user"})
+	})
+	// 没有模板名
+	g.Get("/offered", func(ctx *Context) {
+		ctx.Negotiate(http.StatusOK, Negotiation{Offered: []string{MIMEHTML, MIMEPlain}, Data: "alice"})
+	})
+
+	tests := []struct {
+		path   string
+		accept string
+		code   int
+		body   string
+	}{
+		{"/user", "text/html, application/json;q=0.5", http.StatusOK, `"alice"`},
+		{"/user", "text/html", http.StatusNotAcceptable, ""},
+		{"/offered", "text/html, */*;q=0.1", http.StatusOK, "alice"},
+		{"/offered", "text/html", http.StatusNotAcceptable, ""},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, tt.path, nil)
+		r.Header.Set("Accept", tt.accept)
+		engine.ServeHTTP(w, r)
+		if w.Code != tt.code || w.Body.String() != tt.body {
+			t.Errorf("%s Accept %q: code %d body %q, want %d %q", tt.path, tt.accept, w.Code, w.Body.String(), tt.code, tt.body)
+		}
+	}
+}
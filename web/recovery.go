@@ -27,7 +27,11 @@ func Recovery(next HandlerFunc) HandlerFunc {
 		defer func() {
 			if err := recover(); err != nil {
 				ctx.Logger.Error(detailMsg(err))
-				ctx.Fail(http.StatusInternalServerError, "Internal Server Error")
+				// 响应头已经发出时无法再改状态码
+				if !ctx.W.Written() {
+					ctx.Fail(http.StatusInternalServerError, "Internal Server Error")
+				}
+				ctx.Abort()
 			}
 		}()
 
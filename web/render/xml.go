@@ -0,0 +1,22 @@
+package render
+
+import (
+	"encoding/xml"
+	"net/http"
+)
+
+type XML struct {
+	Data any
+}
+
+const xmlContentType = "application/xml; charset=utf-8"
+
+func (s *XML) Render(w http.ResponseWriter, code int) error {
+	s.WriteContentType(w)
+	w.WriteHeader(code)
+	return xml.NewEncoder(w).Encode(s.Data)
+}
+
+func (s *XML) WriteContentType(w http.ResponseWriter) {
+	writeContentType(w, xmlContentType)
+}
@@ -0,0 +1,70 @@
+package web
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestResponseWriter(t *testing.T) {
+	engine := New()
+	engine.Middleware = nil
+	var out bytes.Buffer
+	engine.MiddlewareHandle(Recovery, func(next HandlerFunc) HandlerFunc {
+		return LoggingWithConfig(&LoggerConfig{
+			Formatter: func(params *LogFormatterParams) string {
+				return params.Path + " " + http.StatusText(params.StatusCode) + "\n"
+			},
+			out: &out,
+		}, next)
+	})
+	g := engine.Group("")
+	g.Get("/write", func(ctx *Context) {
+		ctx.W.Write([]byte("hello"))
+		if !ctx.W.Written() || ctx.W.Size() != 5 {
+			t.Errorf("written %v size %d", ctx.W.Written(), ctx.W.Size())
+		}
+		// 响应头发出之后的状态码被忽略
+		ctx.W.WriteHeader(http.StatusTeapot)
+	})
+	g.Get("/file", func(ctx *Context) {
+		ctx.FileAttachment("testdata/missing.txt", "missing.txt")
+	})
+	g.Get("/panic", func(ctx *Context) {
+		ctx.W.WriteHeader(http.StatusAccepted)
+		panic("boom")
+	})
+	g.Get("/stream", func(ctx *Context) {
+		ctx.W.Flush()
+		if err := ctx.W.Push("/app.css", nil); err != http.ErrNotSupported {
+			t.Errorf("Push error %v", err)
+		}
+		if _, _, err := ctx.W.Hijack(); err == nil {
+			t.Error("Hijack should fail on a recorder")
+		}
+	})
+
+	tests := []struct {
+		path string
+		code int
+		log  string
+	}{
+		{"/write", http.StatusOK, "/write OK"},
+		{"/file", http.StatusNotFound, "/file Not Found"},
+		{"/panic", http.StatusAccepted, "/panic Accepted"},
+		{"/stream", http.StatusOK, "/stream OK"},
+	}
+	for _, tt := range tests {
+		out.Reset()
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.path, nil))
+		if w.Code != tt.code {
+			t.Errorf("%s code %d, want %d", tt.path, w.Code, tt.code)
+		}
+		if got := strings.TrimSpace(out.String()); got != tt.log {
+			t.Errorf("%s log %q, want %q", tt.path, got, tt.log)
+		}
+	}
+}
@@ -0,0 +1,170 @@
+package web
+
+import (
+	"errors"
+	"fmt"
+	"github.com/MucOtto/web/internel/mystrings"
+	"net/url"
+	"reflect"
+	"runtime"
+	"strings"
+)
+
+// Route 注册路由后返回 用于给路由命名以及反向生成地址
+type Route struct {
+	group   *routerGroup
+	path    string
+	methods []string
+	name    string
+}
+
+// Name 给路由命名 名字在整个引擎内唯一
+func (r *Route) Name(name string) *Route {
+	engine := r.group.engine
+	if _, ok := engine.namedRoutes[name]; ok {
+		panic(fmt.Sprintf("web: route name %s is already used", name))
+	}
+	r.name = name
+	engine.namedRoutes[name] = r
+	return r
+}
+
+// ExcludeGlobalMiddleware 该路由不再经过引擎中间件
+func (r *Route) ExcludeGlobalMiddleware() *Route {
+	g := r.group
+	g.engine.mustNotRun("ExcludeGlobalMiddleware")
+	if g.excludedRoutes == nil {
+		g.excludedRoutes = make(map[string]map[string]bool)
+	}
+	if g.excludedRoutes[r.path] == nil {
+		g.excludedRoutes[r.path] = make(map[string]bool)
+	}
+	for _, method := range r.methods {
+		g.excludedRoutes[r.path][method] = true
+		g.tree().routes[r.FullPath()][method].chain = g.buildChain(r.path, method)
+	}
+	return r
+}
+
+// FullPath 加上分组前缀后的完整路由
+func (r *Route) FullPath() string {
+	return joinPath(r.group.prefix, r.path)
+}
+
+// URL 按 key value 成对传入参数生成地址 路由中用不到的参数拼接为查询字符串
+// /users/:id 传入 "id", 42, "tab", "repos" 得到 /users/42?tab=repos
+func (r *Route) URL(params ...any) (string, error) {
+	if len(params)%2 != 0 {
+		return "", errors.New("web: url params must be key value pairs")
+	}
+	values := make(map[string]string, len(params)/2)
+	for i := 0; i < len(params); i += 2 {
+		values[mystrings.ConnectAnyStr(params[i])] = mystrings.ConnectAnyStr(params[i+1])
+	}
+
+	segs := strings.Split(r.FullPath(), "/")
+	for i, seg := range segs {
+		if !strings.HasPrefix(seg, ":") && !strings.HasPrefix(seg, "*") {
+			continue
+		}
+		key := paramKey(seg)
+		value, ok := values[key]
+		if !ok {
+			return "", fmt.Errorf("web: missing param %s for route %s", key, r.FullPath())
+		}
+		delete(values, key)
+		if strings.HasPrefix(seg, "**") {
+			// 剩余路径保留斜杠 每一段单独转义
+			parts := strings.Split(value, "/")
+			for j, part := range parts {
+				parts[j] = url.PathEscape(part)
+			}
+			segs[i] = strings.Join(parts, "/")
+		} else {
+			segs[i] = url.PathEscape(value)
+		}
+	}
+	path := strings.Join(segs, "/")
+
+	query := make(url.Values)
+	for key, value := range values {
+		query.Set(key, value)
+	}
+	if len(query) > 0 {
+		path += "?" + query.Encode()
+	}
+	return path, nil
+}
+
+// URL 根据路由名生成地址 也注册为模板函数 url
+func (e *Engine) URL(name string, params ...any) (string, error) {
+	route, ok := e.namedRoutes[name]
+	if !ok {
+		return "", fmt.Errorf("web: route %s not found", name)
+	}
+	return route.URL(params...)
+}
+
+// RouteInfo 路由表中的一条记录
+type RouteInfo struct {
+	Method      string
+	Path        string
+	Handler     string
+	Middlewares int
+	Name        string
+	// 绑定的域名 普通分组为空
+	Host string
+}
+
+// Routes 按注册顺序返回全部路由
+func (e *Engine) Routes() []RouteInfo {
+	type routeKey struct {
+		group *routerGroup
+		path  string
+	}
+	// 名字属于路由本身 同一路由的所有方法共用
+	names := make(map[routeKey]string, len(e.namedRoutes))
+	for name, route := range e.namedRoutes {
+		names[routeKey{route.group, route.path}] = name
+	}
+	routes := make([]RouteInfo, 0, len(e.routes))
+	for _, route := range e.routes {
+		method := route.methods[0]
+		group := route.group
+		middlewares := len(group.middlewareFuncMap[route.path][method])
+		for g := group; g != nil; g = g.parent {
+			middlewares += len(g.Middlewares) + len(g.handlers)
+		}
+		if !group.globalExcluded(route.path, method) {
+			middlewares += len(e.Middleware) + len(e.handlers)
+		}
+		routes = append(routes, RouteInfo{
+			Method:      method,
+			Path:        route.FullPath(),
+			Handler:     nameOfFunction(group.handlerFuncMap[route.path][method]),
+			Middlewares: middlewares,
+			Name:        names[routeKey{group, route.path}],
+		})
+		if group.host != nil {
+			routes[len(routes)-1].Host = group.host.pattern
+		}
+	}
+	return routes
+}
+
+func (e *Engine) printRoutes() {
+	for _, route := range e.Routes() {
+		msg := fmt.Sprintf("%-7s %-40s --> %s (%d middlewares)", route.Method, route.Path, route.Handler, route.Middlewares)
+		if route.Name != "" {
+			msg += " name=" + route.Name
+		}
+		if route.Host != "" {
+			msg += " host=" + route.Host
+		}
+		e.Logger.Info(msg)
+	}
+}
+
+func nameOfFunction(f any) string {
+	return runtime.FuncForPC(reflect.ValueOf(f).Pointer()).Name()
+}
@@ -0,0 +1,84 @@
+package web
+
+import (
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRouteURL(t *testing.T) {
+	engine := New()
+	h := func(ctx *Context) {}
+	users := engine.Group("/api").Group("/users")
+	users.Get("/:id", h).Name("user.show")
+	users.Get("/:id/files/**path", h).Name("user.file")
+	mustPanic(t, "duplicate name", func() { users.Post("/", h).Name("user.show") })
+
+	tests := []struct {
+		name   string
+		params []any
+		url    string
+	}{
+		{"user.show", []any{"id", 42}, "/api/users/42"},
+		{"user.show", []any{"id", "a b/c"}, "/api/users/a%20b%2Fc"},
+		{"user.show", []any{"id", 1, "tab", "repos", "page", 2}, "/api/users/1?page=2&tab=repos"},
+		{"user.file", []any{"id", 7, "path", "docs/read me.md"}, "/api/users/7/files/docs/read%20me.md"},
+	}
+	for _, test := range tests {
+		url, err := engine.URL(test.name, test.params...)
+		if err != nil || url != test.url {
+			t.Errorf("%s %v: got %q %v, want %q", test.name, test.params, url, err, test.url)
+		}
+	}
+	for _, params := range [][]any{{"id"}, {"name", 1}} {
+		if _, err := engine.URL("user.show", params...); err == nil {
+			t.Errorf("user.show %v: want error", params)
+		}
+	}
+	if _, err := engine.URL("missing"); err == nil {
+		t.Errorf("missing route: want error")
+	}
+}
+
+func TestTemplateURL(t *testing.T) {
+	dir := t.TempDir()
+	tpl := `{{define "index"}}<a href="{{url "user.show" "id" .}}">user</a>{{end}}`
+	if err := os.WriteFile(filepath.Join(dir, "index.html"), []byte(tpl), 0644); err != nil {
+		t.Fatal(err)
+	}
+	engine := New()
+	engine.Group("/users").Get("/:id", func(ctx *Context) {}).Name("user.show")
+	engine.LoadTemplate(filepath.Join(dir, "*.html"))
+
+	w := httptest.NewRecorder()
+	if err := engine.HTMLRender.Template.ExecuteTemplate(w, "index", 42); err != nil {
+		t.Fatal(err)
+	}
+	if got := w.Body.String(); got != `<a href="/users/42">user</a>` {
+		t.Errorf("template output %s", got)
+	}
+}
+
+func listUsers(ctx *Context) {}
+
+func TestRoutes(t *testing.T) {
+	engine := New()
+	api := engine.Group("/api")
+	api.MiddlewareHandle(recordMiddleware(new([]string), "api"))
+	api.Get("/users", listUsers).Name("users")
+	api.Match([]string{"PUT", "PATCH"}, "/users/:id", func(ctx *Context) {}, recordMiddleware(new([]string), "route"))
+
+	routes := engine.Routes()
+	if len(routes) != 3 {
+		t.Fatalf("routes %v", routes)
+	}
+	want := RouteInfo{"GET", "/api/users", "github.com/MucOtto/web.listUsers", 3, "users", ""}
+	if routes[0] != want {
+		t.Errorf("routes[0] = %+v, want %+v", routes[0], want)
+	}
+	if routes[1].Method != "PUT" || routes[2].Method != "PATCH" || routes[2].Path != "/api/users/:id" || routes[2].Middlewares != 4 {
+		t.Errorf("routes %+v", routes[1:])
+	}
+	engine.printRoutes()
+}
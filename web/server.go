@@ -0,0 +1,149 @@
+package web
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+const defaultAddr = ":8080"
+
+// Run 启动HTTP服务 不传地址时监听 :8080
+// 收到 SIGINT SIGTERM 后等待处理中的请求结束再返回
+func (e *Engine) Run(addr ...string) error {
+	return e.RunServer(&http.Server{Addr: resolveAddress(addr), Handler: e})
+}
+
+// RunServer 使用自定义的 http.Server 启动 可以设置超时 请求头大小等参数
+// Handler 为空时使用引擎本身
+func (e *Engine) RunServer(srv *http.Server) error {
+	return e.serve(srv, srv.ListenAndServe)
+}
+
+func resolveAddress(addr []string) string {
+	if len(addr) > 0 && addr[0] != "" {
+		return addr[0]
+	}
+	return defaultAddr
+}
+
+// prepare 启动前统一重建 兼容直接修改Middlewares字段的写法
+// 同时启动多个服务时只执行一次 之后再注册路由或者修改中间件会panic 请求可以并发读取处理链
+func (e *Engine) prepare() {
+	e.prepareOnce.Do(func() {
+		e.rebuildChains()
+		e.running.Store(true)
+		if e.PrintRoutes {
+			e.printRoutes()
+		}
+	})
+}
+
+// mustNotRun 服务启动后处理链被并发读取 不能再修改
+func (e *Engine) mustNotRun(what string) {
+	if e.running.Load() {
+		panic(fmt.Sprintf("web: %s after the server has started", what))
+	}
+}
+
+// serve 登记服务并监听退出信号
+// 由 Engine.Shutdown 关闭时等待其完成 调用方自己关闭 http.Server 时直接返回nil
+func (e *Engine) serve(srv *http.Server, listen func() error) error {
+	e.prepare()
+	if srv.Handler == nil {
+		srv.Handler = e
+	}
+	if e.UseH2C && srv.Handler == http.Handler(e) && srv.TLSConfig == nil {
+		if err := e.configureH2C(srv); err != nil {
+			return err
+		}
+	}
+	e.serverMutex.Lock()
+	// Shutdown 开始之后启动的服务不在它要关闭的列表中 直接拒绝
+	if e.shuttingDown {
+		e.serverMutex.Unlock()
+		return http.ErrServerClosed
+	}
+	e.servers = append(e.servers, srv)
+	e.serverMutex.Unlock()
+
+	stop := e.handleSignals()
+	defer stop()
+	err := listen()
+	if errors.Is(err, http.ErrServerClosed) {
+		e.serverMutex.Lock()
+		shuttingDown := e.shuttingDown
+		e.serverMutex.Unlock()
+		if !shuttingDown {
+			return nil
+		}
+		<-e.shutdownDone
+		return e.shutdownErr
+	}
+	return err
+}
+
+// handleSignals 收到 SIGINT SIGTERM 时优雅关闭 返回的函数用于取消监听
+func (e *Engine) handleSignals() func() {
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, os.Interrupt, syscall.SIGTERM)
+	done := make(chan struct{})
+	go func() {
+		select {
+		case <-sig:
+		case <-done:
+			return
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), e.ShutdownTimeout)
+		defer cancel()
+		if err := e.Shutdown(ctx); err != nil {
+			e.Logger.Error(err)
+		}
+	}()
+	return func() {
+		signal.Stop(sig)
+		close(done)
+	}
+}
+
+// Shutdown 停止接收新连接 等待处理中的请求结束后依次执行OnShutdown注册的函数
+// 只会执行一次 重复调用等待第一次的结果
+func (e *Engine) Shutdown(ctx context.Context) error {
+	e.shutdownOnce.Do(func() {
+		e.serverMutex.Lock()
+		e.shuttingDown = true
+		servers := e.servers
+		e.serverMutex.Unlock()
+		var errs []error
+		for _, srv := range servers {
+			if err := srv.Shutdown(ctx); err != nil {
+				errs = append(errs, err)
+			}
+		}
+		// 后注册的先执行 与 defer 的顺序一致
+		for i := len(e.shutdownHooks) - 1; i >= 0; i-- {
+			e.shutdownHooks[i]()
+		}
+		// 日志文件最后关闭 OnShutdown中仍然可以写日志
+		if err := e.Logger.Close(); err != nil {
+			errs = append(errs, err)
+		}
+		e.shutdownErr = errors.Join(errs...)
+		e.serverMutex.Lock()
+		close(e.shutdownDone)
+		e.serverMutex.Unlock()
+	})
+	<-e.shutdownDone
+	return e.shutdownErr
+}
+
+// OnShutdown 注册服务关闭后执行的函数 用于关闭日志文件 释放协程池等资源
+//
+//	engine.OnShutdown(func() { p.Release() })
+func (e *Engine) OnShutdown(hooks ...func()) {
+	e.shutdownHooks = append(e.shutdownHooks, hooks...)
+}
@@ -0,0 +1,156 @@
+package web
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+)
+
+func freeAddr(t *testing.T) string {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	return l.Addr().String()
+}
+
+func TestGracefulShutdown(t *testing.T) {
+	engine := New()
+	engine.Middleware = nil
+	started := make(chan struct{})
+	release := make(chan struct{})
+	engine.Group("").Get("/slow", func(ctx *Context) {
+		close(started)
+		<-release
+		ctx.String(http.StatusOK, "done")
+	})
+	var hooks []string
+	engine.OnShutdown(func() { hooks = append(hooks, "pool") }, func() { hooks = append(hooks, "db") })
+
+	addr := freeAddr(t)
+	runErr := make(chan error, 1)
+	go func() {
+		runErr <- engine.RunServer(&http.Server{Addr: addr, ReadHeaderTimeout: time.Second})
+	}()
+
+	// 等服务启动后发出一个慢请求
+	var resp *http.Response
+	respErr := make(chan error, 1)
+	go func() {
+		var err error
+		for i := 0; i < 50; i++ {
+			if resp, err = http.Get("http://" + addr + "/slow"); err == nil {
+				break
+			}
+			time.Sleep(20 * time.Millisecond)
+		}
+		respErr <- err
+	}()
+	<-started
+
+	shutdownErr := make(chan error, 1)
+	go func() {
+		shutdownErr <- engine.Shutdown(context.Background())
+	}()
+	// 处理中的请求结束之前 Shutdown 不会返回
+	select {
+	case err := <-shutdownErr:
+		t.Fatalf("Shutdown returned before request finished: %v", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+	// 等待请求结束期间启动的服务直接返回 不会漏掉关闭
+	if err := engine.Run(freeAddr(t)); err != http.ErrServerClosed {
+		t.Errorf("Run during shutdown error %v", err)
+	}
+	close(release)
+
+	if err := <-respErr; err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if string(body) != "done" {
+		t.Errorf("body %q", body)
+	}
+	if err := <-shutdownErr; err != nil {
+		t.Errorf("Shutdown error %v", err)
+	}
+	if err := <-runErr; err != nil {
+		t.Errorf("RunServer error %v", err)
+	}
+	if len(hooks) != 2 || hooks[0] != "db" || hooks[1] != "pool" {
+		t.Errorf("hooks %v", hooks)
+	}
+	// 关闭之后不能再启动
+	if err := engine.Run(addr); err != http.ErrServerClosed {
+		t.Errorf("Run after shutdown error %v", err)
+	}
+}
+
+func TestRunServerOwnShutdown(t *testing.T) {
+	engine := newPingEngine()
+	srv := &http.Server{Addr: freeAddr(t)}
+	runErr := make(chan error, 1)
+	go func() { runErr <- engine.RunServer(srv) }()
+	getPing(t, http.DefaultClient, "http://"+srv.Addr+"/ping")
+
+	// 调用方自己关闭 http.Server 时 RunServer 直接返回
+	if err := srv.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case err := <-runErr:
+		if err != nil {
+			t.Errorf("RunServer error %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("RunServer did not return after srv.Shutdown")
+	}
+}
+
+func TestRunMultipleServers(t *testing.T) {
+	engine := newPingEngine()
+	var listeners []net.Listener
+	runErr := make(chan error, 4)
+	for i := 0; i < 4; i++ {
+		l, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatal(err)
+		}
+		listeners = append(listeners, l)
+	}
+	// 第一个服务开始处理请求之后再启动其余的 处理链不会被重建
+	go func() { runErr <- engine.RunListener(listeners[0]) }()
+	getPing(t, http.DefaultClient, "http://"+listeners[0].Addr().String()+"/ping")
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			getPing(t, http.DefaultClient, "http://"+listeners[0].Addr().String()+"/ping")
+		}()
+	}
+	for _, l := range listeners[1:] {
+		go func(l net.Listener) { runErr <- engine.RunListener(l) }(l)
+	}
+	wg.Wait()
+	for _, l := range listeners[1:] {
+		getPing(t, http.DefaultClient, "http://"+l.Addr().String()+"/ping")
+	}
+	// 启动之后处理链被并发读取 不能再修改
+	mustPanic(t, "Use after start", func() { engine.Use(func(ctx *Context) {}) })
+	mustPanic(t, "MiddlewareHandle after start", func() { engine.MiddlewareHandle(nopMiddleware) })
+	mustPanic(t, "route after start", func() { engine.Group("").Get("/late", func(ctx *Context) {}) })
+	mustPanic(t, "NoRoute after start", func() { engine.NoRoute(defaultNoRoute) })
+	engine.Shutdown(context.Background())
+	for range listeners {
+		if err := <-runErr; err != nil {
+			t.Errorf("RunListener error %v", err)
+		}
+	}
+}
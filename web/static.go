@@ -0,0 +1,145 @@
+package web
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"strings"
+)
+
+// StaticConfig 静态文件服务的配置
+type StaticConfig struct {
+	// Browse 目录下没有首页时列出目录中的文件
+	Browse bool
+	// Index 目录的首页文件 默认为 index.html
+	Index string
+	// SPA 找不到文件时返回根目录的首页 交给前端路由处理
+	SPA bool
+}
+
+const staticParam = "filepath"
+
+// Static 把本地目录root挂载到prefix下
+func (r *routerGroup) Static(prefix, root string) {
+	r.StaticFSWithConfig(prefix, os.DirFS(root), nil)
+}
+
+// StaticFS 挂载任意fs.FS 包括embed.FS 需要去掉目录前缀时先用fs.Sub
+func (r *routerGroup) StaticFS(prefix string, fsys fs.FS) {
+	r.StaticFSWithConfig(prefix, fsys, nil)
+}
+
+func (r *routerGroup) StaticFSWithConfig(prefix string, fsys fs.FS, conf *StaticConfig) {
+	if conf == nil {
+		conf = &StaticConfig{}
+	}
+	index := conf.Index
+	if index == "" {
+		index = "index.html"
+	}
+	prefix = strings.TrimSuffix(prefix, "/")
+	r.Get(prefix+"/**"+staticParam, func(ctx *Context) {
+		serveStatic(ctx, fsys, index, conf)
+	})
+}
+
+// StaticFile 把单个文件注册为路由
+func (r *routerGroup) StaticFile(name, file string) {
+	r.Get(name, func(ctx *Context) {
+		http.ServeFile(ctx.W, ctx.R, file)
+	})
+}
+
+func serveStatic(ctx *Context, fsys fs.FS, index string, conf *StaticConfig) {
+	name := strings.TrimSuffix(ctx.Param(staticParam), "/")
+	if name == "" {
+		name = "."
+	}
+	// 拒绝 .. 以及不规范的路径 防止访问到目录之外的文件
+	if !fs.ValidPath(name) || strings.Contains(name, "\\") {
+		ctx.Fail(http.StatusBadRequest, "invalid path")
+		return
+	}
+
+	stat, err := fs.Stat(fsys, name)
+	if err == nil && stat.IsDir() {
+		if !strings.HasSuffix(ctx.R.URL.Path, "/") {
+			// 目录统一以 / 结尾 保证页面中的相对路径正确 保留转义后的路径和查询参数
+			location := ctx.R.URL.EscapedPath() + "/"
+			if ctx.R.URL.RawQuery != "" {
+				location += "?" + ctx.R.URL.RawQuery
+			}
+			ctx.Redirect(http.StatusMovedPermanently, location)
+			return
+		}
+		indexName := path.Join(name, index)
+		if indexStat, err := fs.Stat(fsys, indexName); err == nil && !indexStat.IsDir() {
+			serveFS(ctx, fsys, indexName, indexStat)
+			return
+		}
+		if conf.Browse {
+			listDir(ctx, fsys, name)
+			return
+		}
+		err = fs.ErrNotExist
+	}
+	if err != nil {
+		if conf.SPA {
+			if indexStat, err := fs.Stat(fsys, index); err == nil && !indexStat.IsDir() {
+				serveFS(ctx, fsys, index, indexStat)
+				return
+			}
+		}
+		ctx.engine.notFound(ctx)
+		return
+	}
+	serveFS(ctx, fsys, name, stat)
+}
+
+func serveFS(ctx *Context, fsys fs.FS, name string, stat fs.FileInfo) {
+	f, err := fsys.Open(name)
+	if err != nil {
+		ctx.engine.notFound(ctx)
+		return
+	}
+	defer f.Close()
+	content, ok := f.(io.ReadSeeker)
+	if !ok {
+		data, err := io.ReadAll(f)
+		if err != nil {
+			ctx.Fail(http.StatusInternalServerError, "Internal Server Error")
+			return
+		}
+		content = bytes.NewReader(data)
+	}
+	http.ServeContent(ctx.W, ctx.R, stat.Name(), stat.ModTime(), content)
+}
+
+func listDir(ctx *Context, fsys fs.FS, name string) {
+	entries, err := fs.ReadDir(fsys, name)
+	if err != nil {
+		ctx.Fail(http.StatusInternalServerError, "Error reading directory")
+		return
+	}
+	var sb strings.Builder
+	sb.WriteString("<pre>\n")
+	for _, entry := range entries {
+		entryName := entry.Name()
+		if entry.IsDir() {
+			entryName += "/"
+		}
+		u := url.URL{Path: entryName}
+		sb.WriteString(fmt.Sprintf("<a href=\"%s\">%s</a>\n", u.String(), html.EscapeString(entryName)))
+	}
+	sb.WriteString("</pre>\n")
+	ctx.W.Header().Set("Content-Type", "text/html; charset=utf-8")
+	ctx.W.WriteHeader(http.StatusOK)
+	ctx.StatusCode = http.StatusOK
+	io.WriteString(ctx.W, sb.String())
+}
@@ -0,0 +1,66 @@
+package web
+
+import (
+	"embed"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+//go:embed testdata/static
+var staticFS embed.FS
+
+func TestStatic(t *testing.T) {
+	sub, err := fs.Sub(staticFS, "testdata/static")
+	if err != nil {
+		t.Fatal(err)
+	}
+	engine := New()
+	g := engine.Group("/")
+	g.StaticFS("/assets", sub)
+	g.Static("/local", "testdata/static")
+	g.StaticFSWithConfig("/browse", sub, &StaticConfig{Browse: true})
+	g.StaticFSWithConfig("/app", sub, &StaticConfig{SPA: true})
+	g.StaticFile("/favicon.css", "testdata/static/app.css")
+	// 文件不存在和路由不存在一样经过NoRoute的中间件
+	engine.NoRoute(func(ctx *Context) {
+		ctx.String(http.StatusNotFound, "custom 404")
+	}, func(next HandlerFunc) HandlerFunc {
+		return func(ctx *Context) {
+			ctx.W.Header().Set("X-Not-Found", "1")
+			next(ctx)
+		}
+	})
+
+	tests := []struct {
+		path string
+		code int
+		body string
+	}{
+		{"/assets/app.css", http.StatusOK, "body{}"},
+		{"/assets/", http.StatusOK, "<h1>home</h1>"},
+		{"/assets", http.StatusMovedPermanently, "/assets/"},
+		{"/assets/docs", http.StatusMovedPermanently, ""},
+		{"/assets/docs?x=1", http.StatusMovedPermanently, "/assets/docs/?x=1"},
+		{"/assets/docs/", http.StatusNotFound, ""},
+		{"/assets/missing.js", http.StatusNotFound, "custom 404"},
+		{"/assets/../static_test.go", http.StatusBadRequest, ""},
+		{"/local/docs/guide.txt", http.StatusOK, "guide"},
+		{"/local/..%2fstatic_test.go", http.StatusBadRequest, ""},
+		{"/browse/docs/", http.StatusOK, `<a href="guide.txt">guide.txt</a>`},
+		{"/app/users/42", http.StatusOK, "<h1>home</h1>"},
+		{"/favicon.css", http.StatusOK, "body{}"},
+	}
+	for _, test := range tests {
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, test.path, nil))
+		if w.Code != test.code || !strings.Contains(w.Body.String(), test.body) {
+			t.Errorf("%s: code %d body %q, want %d %q", test.path, w.Code, w.Body.String(), test.code, test.body)
+		}
+		if test.code == http.StatusNotFound && w.Header().Get("X-Not-Found") != "1" {
+			t.Errorf("%s: NoRoute middleware not applied", test.path)
+		}
+	}
+}
@@ -0,0 +1,146 @@
+package web
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+// certCheckInterval 两次检查证书文件是否变化的最短间隔 避免每次握手都读取文件
+var certCheckInterval = time.Second
+
+// RunTLS 启动HTTPS服务 证书文件变化后自动加载 不需要重启
+func (e *Engine) RunTLS(addr, certFile, keyFile string) error {
+	return e.RunTLSWithConfig(addr, certFile, keyFile, nil)
+}
+
+// RunTLSWithConfig 使用自定义的 tls.Config 启动 设置 ClientAuth ClientCAs 即可校验客户端证书
+//
+//	pool, _ := web.LoadClientCAs("ca.pem")
+//	engine.RunTLSWithConfig(":443", "cert.pem", "key.pem", &tls.Config{
+//		ClientAuth: tls.RequireAndVerifyClientCert,
+//		ClientCAs:  pool,
+//	})
+func (e *Engine) RunTLSWithConfig(addr, certFile, keyFile string, config *tls.Config) error {
+	tlsConfig, err := newTLSConfig(certFile, keyFile, config)
+	if err != nil {
+		return err
+	}
+	srv := &http.Server{Addr: addr, TLSConfig: tlsConfig}
+	return e.serve(srv, func() error {
+		// 证书由 GetCertificate 提供
+		return srv.ListenAndServeTLS("", "")
+	})
+}
+
+// newTLSConfig 复制一份配置 证书改为从文件动态加载
+func newTLSConfig(certFile, keyFile string, config *tls.Config) (*tls.Config, error) {
+	reloader, err := newCertReloader(certFile, keyFile)
+	if err != nil {
+		return nil, err
+	}
+	if config == nil {
+		config = &tls.Config{}
+	} else {
+		config = config.Clone()
+	}
+	if config.MinVersion == 0 {
+		config.MinVersion = tls.VersionTLS12
+	}
+	config.Certificates = nil
+	config.GetCertificate = reloader.GetCertificate
+	return config, nil
+}
+
+// LoadClientCAs 读取PEM格式的CA证书 用于 tls.Config.ClientCAs 校验客户端证书
+func LoadClientCAs(files ...string) (*x509.CertPool, error) {
+	pool := x509.NewCertPool()
+	for _, file := range files {
+		pem, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("web: no certificate found in %s", file)
+		}
+	}
+	return pool, nil
+}
+
+// certReloader 握手时检查证书文件的修改时间 有变化就重新加载
+// 新证书加载失败时继续使用旧证书
+type certReloader struct {
+	certFile string
+	keyFile  string
+	mu       sync.RWMutex
+	cert     *tls.Certificate
+	certMod  time.Time
+	keyMod   time.Time
+	checked  time.Time
+}
+
+func newCertReloader(certFile, keyFile string) (*certReloader, error) {
+	r := &certReloader{certFile: certFile, keyFile: keyFile}
+	if err := r.reload(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func (r *certReloader) reload() error {
+	certStat, err := os.Stat(r.certFile)
+	if err != nil {
+		return err
+	}
+	keyStat, err := os.Stat(r.keyFile)
+	if err != nil {
+		return err
+	}
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return err
+	}
+	r.cert = &cert
+	r.certMod = certStat.ModTime()
+	r.keyMod = keyStat.ModTime()
+	return nil
+}
+
+// modified 证书或者私钥文件的修改时间是否变化
+func (r *certReloader) modified() bool {
+	certStat, err := os.Stat(r.certFile)
+	if err != nil {
+		return false
+	}
+	keyStat, err := os.Stat(r.keyFile)
+	if err != nil {
+		return false
+	}
+	return !certStat.ModTime().Equal(r.certMod) || !keyStat.ModTime().Equal(r.keyMod)
+}
+
+func (r *certReloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	r.mu.RLock()
+	cert, checked := r.cert, r.checked
+	r.mu.RUnlock()
+	if time.Since(checked) < certCheckInterval {
+		return cert, nil
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	// 其他握手可能已经检查过
+	if time.Since(r.checked) < certCheckInterval {
+		return r.cert, nil
+	}
+	r.checked = time.Now()
+	if r.modified() {
+		// 证书和私钥可能没有同时写完 加载失败时继续使用旧证书 下个间隔再试
+		r.reload()
+	}
+	return r.cert, nil
+}
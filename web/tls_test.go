@@ -0,0 +1,179 @@
+package web
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io"
+	"log"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+type testCert struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	der  []byte
+}
+
+// newTestCert 生成测试用证书 parent为nil时生成自签名的CA
+func newTestCert(t *testing.T, cn string, parent *testCert, usage x509.ExtKeyUsage) *testCert {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	serial, _ := rand.Int(rand.Reader, big.NewInt(1<<62))
+	tmpl := &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: cn},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		DNSNames:     []string{"localhost"},
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{usage},
+	}
+	signer, signerKey := tmpl, key
+	if parent == nil {
+		tmpl.IsCA = true
+		tmpl.BasicConstraintsValid = true
+		tmpl.KeyUsage |= x509.KeyUsageCertSign
+		tmpl.ExtKeyUsage = nil
+	} else {
+		signer, signerKey = parent.cert, parent.key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, signer, &key.PublicKey, signerKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, _ := x509.ParseCertificate(der)
+	return &testCert{cert: cert, key: key, der: der}
+}
+
+func (c *testCert) writeFiles(t *testing.T, certFile, keyFile string) {
+	keyDER, err := x509.MarshalECPrivateKey(c.key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: c.der}), 0600)
+	os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0600)
+}
+
+func TestMutualTLS(t *testing.T) {
+	dir := t.TempDir()
+	caFile := filepath.Join(dir, "ca.pem")
+	certFile := filepath.Join(dir, "cert.pem")
+	keyFile := filepath.Join(dir, "key.pem")
+	ca := newTestCert(t, "test ca", nil, x509.ExtKeyUsageServerAuth)
+	ca.writeFiles(t, caFile, filepath.Join(dir, "ca-key.pem"))
+	newTestCert(t, "server v1", ca, x509.ExtKeyUsageServerAuth).writeFiles(t, certFile, keyFile)
+	client := newTestCert(t, "alice", ca, x509.ExtKeyUsageClientAuth)
+
+	clientCAs, err := LoadClientCAs(caFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	config, err := newTLSConfig(certFile, keyFile, &tls.Config{
+		ClientAuth: tls.RequireAndVerifyClientCert,
+		ClientCAs:  clientCAs,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	engine := New()
+	engine.Middleware = nil
+	engine.Group("").Get("/whoami", func(ctx *Context) {
+		ctx.String(http.StatusOK, "%s", ctx.ClientCertificate().Subject.CommonName)
+	})
+	srv := httptest.NewUnstartedServer(engine)
+	srv.TLS = config
+	// 不打印握手失败的日志
+	srv.Config.ErrorLog = log.New(io.Discard, "", 0)
+	srv.StartTLS()
+	defer srv.Close()
+
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.cert)
+	request := func(certs []tls.Certificate) (string, string, error) {
+		c := &http.Client{Transport: &http.Transport{
+			// 发送SNI 证书才会由GetCertificate提供
+			TLSClientConfig:   &tls.Config{RootCAs: roots, ServerName: "localhost", Certificates: certs},
+			DisableKeepAlives: true,
+		}}
+		resp, err := c.Get(srv.URL + "/whoami")
+		if err != nil {
+			return "", "", err
+		}
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		return string(body), resp.TLS.PeerCertificates[0].Subject.CommonName, nil
+	}
+	clientCert := []tls.Certificate{{Certificate: [][]byte{client.der}, PrivateKey: client.key}}
+
+	if _, _, err := request(nil); err == nil {
+		t.Error("request without client certificate should fail")
+	}
+	body, server, err := request(clientCert)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if body != "alice" || server != "server v1" {
+		t.Errorf("body %q server %q", body, server)
+	}
+
+	// 替换证书文件 不重启就生效
+	certCheckInterval = 0
+	defer func() { certCheckInterval = time.Second }()
+	newTestCert(t, "server v2", ca, x509.ExtKeyUsageServerAuth).writeFiles(t, certFile, keyFile)
+	later := time.Now().Add(time.Minute)
+	os.Chtimes(certFile, later, later)
+	os.Chtimes(keyFile, later, later)
+	if _, server, err = request(clientCert); err != nil {
+		t.Fatal(err)
+	}
+	if server != "server v2" {
+		t.Errorf("server certificate %q after reload, want server v2", server)
+	}
+}
+
+func TestCertReloaderKeepsOldCert(t *testing.T) {
+	dir := t.TempDir()
+	certFile := filepath.Join(dir, "cert.pem")
+	keyFile := filepath.Join(dir, "key.pem")
+	ca := newTestCert(t, "test ca", nil, x509.ExtKeyUsageServerAuth)
+	newTestCert(t, "server v1", ca, x509.ExtKeyUsageServerAuth).writeFiles(t, certFile, keyFile)
+	r, err := newCertReloader(certFile, keyFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	old := r.cert
+
+	// 私钥还没有写完
+	os.WriteFile(keyFile, []byte("partial"), 0600)
+	later := time.Now().Add(time.Minute)
+	os.Chtimes(keyFile, later, later)
+	r.checked = time.Time{}
+	cert, err := r.GetCertificate(nil)
+	if err != nil || cert != old {
+		t.Fatalf("GetCertificate after failed reload: %v, want old certificate", err)
+	}
+	// 失败后仍然等待下个间隔 不在每次握手时重新读取
+	if r.checked.IsZero() {
+		t.Error("check interval reset after failed reload")
+	}
+	checked := r.checked
+	r.GetCertificate(nil)
+	if !r.checked.Equal(checked) {
+		t.Error("reloaded again within the check interval")
+	}
+}
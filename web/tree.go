@@ -1,85 +1,349 @@
 package web
 
-import "strings"
+import (
+	"fmt"
+	"strings"
+)
 
-// uri的前缀树
+// uri的压缩前缀树(radix tree)
+// 同一位置的匹配优先级: 静态 > :name > * > **
+// 匹配失败时会回溯到下一优先级的节点
+
+type nodeKind uint8
+
+const (
+	staticKind   nodeKind = iota
+	paramKind             // :name 匹配一段
+	wildKind              // * 匹配一段
+	catchAllKind          // ** 匹配剩余的全部路径
+)
 
 type treeNode struct {
-	val        string
-	children   []*treeNode
+	// 静态节点为压缩后的公共前缀 其余为注册时的原始段 如 :id * **filepath
+	val  string
+	kind nodeKind
+	// 参数名 静态节点为空
+	key string
+	// 静态子节点的首字节 和children一一对应
+	indices  string
+	children []*treeNode
+	// 同一位置可以有多个约束不同的参数 带约束的排在前面
+	paramChildren []*treeNode
+	wildChild     *treeNode
+	catchAll      *treeNode
+	// 注册时的完整路由 只在isEnd的节点上有值 注册完成后不再修改
 	routerName string
 	isEnd      bool
+	// 参数节点上的约束
+	constraint *paramConstraint
 }
 
-func (t *treeNode) Put(path string) {
-	root := t
-	strs := strings.Split(path, "/")
-	for index, str := range strs {
-		// 刚开始等于空的情况
-		if index == 0 {
-			continue
+// Param 路由匹配出来的参数 /user/:id 中的 id
+type Param struct {
+	Key   string
+	Value string
+	// 带类型约束的参数在匹配时已经解析好的值
+	kind       constraintKind
+	intValue   int64
+	floatValue float64
+}
+
+type Params []Param
+
+// Get 按名字取参数 不存在时ok为false
+func (ps Params) Get(name string) (string, bool) {
+	p, ok := ps.param(name)
+	return p.Value, ok
+}
+
+func (ps Params) param(name string) (Param, bool) {
+	for _, p := range ps {
+		if p.Key == name {
+			return p, true
 		}
-		children := t.children
-		isMatch := false
-		for _, node := range children {
-			if node.val == str {
-				isMatch = true
-				t = node
-				break
-			}
+	}
+	return Param{}, false
+}
+
+// Put 注册路由 和已有路由冲突时返回错误
+func (t *treeNode) Put(path string) error {
+	if !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+	routerName := path
+	n := t
+	var err error
+	for len(path) > 0 {
+		i := wildcardIndex(path)
+		if i < 0 {
+			n = n.putStatic(path)
+			break
+		}
+		if i > 0 {
+			n = n.putStatic(path[:i])
+		}
+		seg, rest := path[i:], ""
+		if j := strings.IndexByte(seg, '/'); j >= 0 {
+			seg, rest = seg[:j], seg[j:]
+		}
+		if strings.HasPrefix(seg, "**") && rest != "" {
+			return fmt.Errorf("catch-all %s must be the last segment", seg)
+		}
+		if n, err = n.putWild(seg); err != nil {
+			return err
+		}
+		path = rest
+	}
+	n.isEnd = true
+	n.routerName = routerName
+	return nil
+}
+
+// putStatic 把静态前缀插入到n的子节点中 必要时拆分已有节点
+func (n *treeNode) putStatic(path string) *treeNode {
+	for {
+		i := strings.IndexByte(n.indices, path[0])
+		if i < 0 {
+			child := &treeNode{val: path, kind: staticKind}
+			n.indices += string(path[0])
+			n.children = append(n.children, child)
+			return child
+		}
+		child := n.children[i]
+		l := commonPrefix(path, child.val)
+		if l < len(child.val) {
+			child.split(l)
+		}
+		if l == len(path) {
+			return child
+		}
+		n, path = child, path[l:]
+	}
+}
+
+// split 在第l个字节处把节点拆成两个 后半部分继承原节点的子节点和路由
+func (n *treeNode) split(l int) {
+	tail := *n
+	tail.val = n.val[l:]
+	*n = treeNode{
+		val:      n.val[:l],
+		kind:     staticKind,
+		indices:  string(tail.val[0]),
+		children: []*treeNode{&tail},
+	}
+}
+
+// putWild 插入通配段 同一位置只允许一个同类通配段 名字不同视为冲突
+// 参数例外 约束不同的参数可以共存 匹配时依次尝试
+func (n *treeNode) putWild(seg string) (*treeNode, error) {
+	var child **treeNode
+	kind := paramKind
+	switch {
+	case strings.HasPrefix(seg, "**"):
+		child, kind = &n.catchAll, catchAllKind
+	case seg == "*":
+		child, kind = &n.wildChild, wildKind
+	case len(seg) < 2 || seg[0] != ':':
+		return nil, fmt.Errorf("invalid wildcard segment %s", seg)
+	default:
+		return n.putParam(seg)
+	}
+	if *child == nil {
+		*child = &treeNode{val: seg, kind: kind, key: paramKey(seg)}
+	} else if (*child).val != seg {
+		return nil, fmt.Errorf("%s conflicts with existing %s", seg, (*child).val)
+	}
+	return *child, nil
+}
+
+func (n *treeNode) putParam(seg string) (*treeNode, error) {
+	key, constraint, err := parseParam(seg)
+	if err != nil {
+		return nil, err
+	}
+	for _, child := range n.paramChildren {
+		if child.val == seg {
+			return child, nil
+		}
+		if child.constraint.String() == constraint.String() {
+			return nil, fmt.Errorf("%s conflicts with existing %s", seg, child.val)
+		}
+	}
+	child := &treeNode{val: seg, kind: paramKind, key: key, constraint: constraint}
+	// 没有约束的参数最多一个 始终放在最后
+	i := len(n.paramChildren)
+	if constraint != nil && i > 0 && n.paramChildren[i-1].constraint == nil {
+		i--
+	}
+	n.paramChildren = append(n.paramChildren, nil)
+	copy(n.paramChildren[i+1:], n.paramChildren[i:])
+	n.paramChildren[i] = child
+	return child, nil
+}
+
+// Get 匹配路由 :name 和 * 匹配到的值以及 ** 剩余的路径会追加到params中
+// 匹配过程不修改树 params容量足够时也不会分配内存
+func (t *treeNode) Get(path string, params *Params) *treeNode {
+	if strings.HasPrefix(path, t.val) {
+		return t.match(path[len(t.val):], params)
+	}
+	return nil
+}
+
+// match 在n的子节点中匹配n之后剩余的path
+func (n *treeNode) match(path string, params *Params) *treeNode {
+	if path == "" {
+		if n.isEnd {
+			return n
 		}
-		if !isMatch {
-			isEnd := false
-			if index == len(strs)-1 {
-				isEnd = true
+		// /static/** 可以匹配 /static/
+		if n.catchAll != nil {
+			*params = append(*params, Param{Key: n.catchAll.key})
+			return n.catchAll
+		}
+		return nil
+	}
+
+	if i := strings.IndexByte(n.indices, path[0]); i >= 0 {
+		child := n.children[i]
+		if strings.HasPrefix(path, child.val) {
+			if node := child.match(path[len(child.val):], params); node != nil {
+				return node
 			}
-			node := &treeNode{
-				val:      str,
-				children: make([]*treeNode, 0),
-				isEnd:    isEnd,
+		}
+	}
+
+	seg := path
+	if i := strings.IndexByte(path, '/'); i >= 0 {
+		seg = path[:i]
+	}
+	if seg != "" {
+		for _, child := range n.paramChildren {
+			if node := child.matchSegment(seg, path[len(seg):], params); node != nil {
+				return node
 			}
-			t.children = append(children, node)
-			t = node
 		}
+		if node := n.wildChild.matchSegment(seg, path[len(seg):], params); node != nil {
+			return node
+		}
+	}
+
+	if n.catchAll != nil {
+		*params = append(*params, Param{Key: n.catchAll.key, Value: path})
+		return n.catchAll
 	}
-	t = root
+	return nil
 }
 
-func (t *treeNode) Get(path string) *treeNode {
-	strs := strings.Split(path, "/")
-	routerName := ""
-	for index, val := range strs {
-		if index == 0 {
-			continue
+// findCaseInsensitive 忽略大小写匹配 返回按注册时大小写修正后的路径 参数部分保持原样
+func (t *treeNode) findCaseInsensitive(path string) (string, bool) {
+	if len(path) < len(t.val) || !strings.EqualFold(path[:len(t.val)], t.val) {
+		return "", false
+	}
+	return t.matchFold(path[len(t.val):], t.val)
+}
+
+func (n *treeNode) matchFold(path, fixed string) (string, bool) {
+	if path == "" {
+		if n.isEnd || n.catchAll != nil {
+			return fixed, true
 		}
-		children := t.children
-		isMatch := false
-		for _, node := range children {
-			if node.val == val ||
-				node.val == "*" ||
-				strings.Contains(node.val, ":") {
-				isMatch = true
-				routerName += "/" + node.val
-				node.routerName = routerName
-				t = node
-				if index == len(strs)-1 {
-					return node
-				}
-				break
+		return "", false
+	}
+	for _, child := range n.children {
+		if len(path) >= len(child.val) && strings.EqualFold(path[:len(child.val)], child.val) {
+			if p, ok := child.matchFold(path[len(child.val):], fixed+child.val); ok {
+				return p, true
 			}
 		}
-		if !isMatch {
-			for _, node := range children {
-				// /user/**
-				// /user/get/userInfo
-				// /user/aa/bb
-				if node.val == "**" {
-					routerName += "/" + node.val
-					node.routerName = routerName
-					return node
-				}
+	}
+	seg := path
+	if i := strings.IndexByte(path, '/'); i >= 0 {
+		seg = path[:i]
+	}
+	if seg != "" {
+		for _, child := range n.paramChildren {
+			if !child.constraint.check(&Param{Value: seg}) {
+				continue
+			}
+			if p, ok := child.matchFold(path[len(seg):], fixed+seg); ok {
+				return p, true
+			}
+		}
+		if n.wildChild != nil {
+			if p, ok := n.wildChild.matchFold(path[len(seg):], fixed+seg); ok {
+				return p, true
 			}
 		}
 	}
+	if n.catchAll != nil {
+		return fixed + path, true
+	}
+	return "", false
+}
+
+// matchSegment 用n匹配一段seg 失败时撤销追加的参数
+func (n *treeNode) matchSegment(seg, rest string, params *Params) *treeNode {
+	if n == nil {
+		return nil
+	}
+	p := Param{Key: n.key, Value: seg}
+	if !n.constraint.check(&p) {
+		return nil
+	}
+	l := len(*params)
+	*params = append(*params, p)
+	if node := n.match(rest, params); node != nil {
+		return node
+	}
+	*params = (*params)[:l]
 	return nil
 }
+
+// wildcardIndex 返回第一个通配段的起始位置 没有时返回-1
+func wildcardIndex(path string) int {
+	for i := 0; i < len(path); i++ {
+		if path[i] != '/' || i+1 >= len(path) {
+			continue
+		}
+		if c := path[i+1]; c == ':' || c == '*' {
+			return i + 1
+		}
+	}
+	return -1
+}
+
+func commonPrefix(a, b string) int {
+	i := 0
+	for i < len(a) && i < len(b) && a[i] == b[i] {
+		i++
+	}
+	return i
+}
+
+// countParams 统计路由中的参数个数 用于预先分配Context中的参数
+func countParams(path string) int {
+	n := 0
+	for _, seg := range strings.Split(path, "/") {
+		if strings.HasPrefix(seg, ":") || strings.HasPrefix(seg, "*") {
+			n++
+		}
+	}
+	return n
+}
+
+// paramKey 取出段对应的参数名
+// :id -> id  :id<int> -> id  ** -> **  **filepath -> filepath  * -> *
+func paramKey(val string) string {
+	if i := strings.IndexByte(val, ':'); i >= 0 {
+		key := val[i+1:]
+		if j := strings.IndexByte(key, '<'); j >= 0 {
+			key = key[:j]
+		}
+		return key
+	}
+	if len(val) > 2 && strings.HasPrefix(val, "**") {
+		return val[2:]
+	}
+	return val
+}
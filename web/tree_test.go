@@ -0,0 +1,210 @@
+package web
+
+import (
+	"testing"
+)
+
+func newTestTree(paths ...string) *treeNode {
+	root := &treeNode{}
+	for _, path := range paths {
+		root.Put(path)
+	}
+	return root
+}
+
+func TestTreePriority(t *testing.T) {
+	// 注册顺序故意把通配放在前面
+	root := newTestTree(
+		"/user/**",
+		"/user/*",
+		"/user/:id",
+		"/user/profile",
+		"/user/:id/info",
+		"/user/*/settings",
+		"/",
+	)
+	tests := []struct {
+		path   string
+		router string
+		params Params
+	}{
+		{"/", "/", nil},
+		{"/user/profile", "/user/profile", nil},
+		{"/user/42", "/user/:id", Params{{Key: "id", Value: "42"}}},
+		{"/user/42/info", "/user/:id/info", Params{{Key: "id", Value: "42"}}},
+		{"/user/42/settings", "/user/*/settings", Params{{Key: "*", Value: "42"}}},
+		{"/user/profile/info", "/user/:id/info", Params{{Key: "id", Value: "profile"}}},
+		{"/user/42/a/b", "/user/**", Params{{Key: "**", Value: "42/a/b"}}},
+		{"/user/", "/user/**", Params{{Key: "**", Value: ""}}},
+	}
+	for _, test := range tests {
+		params := make(Params, 0, 4)
+		node := root.Get(test.path, &params)
+		if node == nil {
+			t.Errorf("%s: no route matched", test.path)
+			continue
+		}
+		if node.routerName != test.router {
+			t.Errorf("%s: matched %s, want %s", test.path, node.routerName, test.router)
+		}
+		if len(params) != len(test.params) {
+			t.Errorf("%s: params %v, want %v", test.path, params, test.params)
+			continue
+		}
+		for i := range params {
+			if params[i] != test.params[i] {
+				t.Errorf("%s: params %v, want %v", test.path, params, test.params)
+			}
+		}
+	}
+}
+
+func TestTreeNotFound(t *testing.T) {
+	root := newTestTree("/user/:id/info", "/users", "/user/list")
+	for _, path := range []string{"/user", "/user/", "/user/42", "/user/42/info/x", "/use", "/"} {
+		params := make(Params, 0, 4)
+		if node := root.Get(path, &params); node != nil {
+			t.Errorf("%s: unexpected match %s", path, node.routerName)
+		}
+		if len(params) != 0 {
+			t.Errorf("%s: params leaked after backtracking: %v", path, params)
+		}
+	}
+}
+
+func TestTreeSplit(t *testing.T) {
+	root := newTestTree("/contact", "/co", "/c", "/contacts/:name", "/cow")
+	for _, path := range []string{"/contact", "/co", "/c", "/cow"} {
+		params := make(Params, 0, 1)
+		node := root.Get(path, &params)
+		if node == nil || node.routerName != path {
+			t.Errorf("%s: got %v", path, node)
+		}
+	}
+	params := make(Params, 0, 1)
+	if node := root.Get("/contacts/tom", &params); node == nil || params[0].Value != "tom" {
+		t.Errorf("/contacts/tom: got %v %v", node, params)
+	}
+}
+
+func TestTreeConflict(t *testing.T) {
+	tests := []struct {
+		exist, path string
+	}{
+		{"/a/:id", "/a/:name"},
+		{"/a/:id/info", "/a/:name"},
+		{"/files/**path", "/files/**name"},
+		{"", "/files/**path/info"},
+		{"", "/a/:"},
+	}
+	for _, test := range tests {
+		root := newTestTree()
+		if test.exist != "" {
+			if err := root.Put(test.exist); err != nil {
+				t.Fatalf("%s: %v", test.exist, err)
+			}
+		}
+		if err := root.Put(test.path); err == nil {
+			t.Errorf("%s after %q: want conflict error", test.path, test.exist)
+		} else {
+			t.Logf("%s: %v", test.path, err)
+		}
+	}
+	// 同名参数 不同方法共用同一个节点
+	root := newTestTree("/a/:id")
+	if err := root.Put("/a/:id/info"); err != nil {
+		t.Errorf("/a/:id/info: %v", err)
+	}
+}
+
+var benchRoutes = []string{
+	"/",
+	"/user/login",
+	"/user/logout",
+	"/user/:id",
+	"/user/:id/profile",
+	"/user/:id/repos/:repo",
+	"/user/:id/repos/:repo/issues/:number",
+	"/repos/:owner/:repo/contents/**path",
+	"/static/*",
+	"/orders",
+	"/orders/:id",
+	"/orders/:id/items",
+	"/search",
+}
+
+func benchmarkTree(b *testing.B, path string) {
+	root := newTestTree(benchRoutes...)
+	params := make(Params, 0, countParams("/:a/:b/:c"))
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		params = params[:0]
+		if root.Get(path, &params) == nil {
+			b.Fatalf("%s: no route matched", path)
+		}
+	}
+}
+
+func BenchmarkTreeStatic(b *testing.B) {
+	benchmarkTree(b, "/user/logout")
+}
+
+func BenchmarkTreeParam(b *testing.B) {
+	benchmarkTree(b, "/user/42")
+}
+
+func BenchmarkTreeParams3(b *testing.B) {
+	benchmarkTree(b, "/user/42/repos/web/issues/7")
+}
+
+func BenchmarkTreeCatchAll(b *testing.B) {
+	benchmarkTree(b, "/repos/otto/web/contents/web/tree.go")
+}
+
+func BenchmarkTreeBacktrack(b *testing.B) {
+	benchmarkTree(b, "/orders/42/items")
+}
+
+func TestTreeConstraint(t *testing.T) {
+	root := newTestTree(
+		"/users/:id<int>",
+		"/users/:name",
+		"/users/:slug<uuid>",
+		"/files/:name<[a-z0-9_-]+>",
+		"/price/:amount<float>",
+	)
+	tests := []struct {
+		path, router string
+	}{
+		{"/users/42", "/users/:id<int>"},
+		{"/users/6ba7b810-9dad-11d1-80b4-00c04fd430c8", "/users/:slug<uuid>"},
+		{"/users/tom", "/users/:name"},
+		{"/files/read_me-1", "/files/:name<[a-z0-9_-]+>"},
+		{"/files/README", ""},
+		{"/price/9.5", "/price/:amount<float>"},
+		{"/price/free", ""},
+	}
+	for _, test := range tests {
+		params := make(Params, 0, 1)
+		node := root.Get(test.path, &params)
+		switch {
+		case node == nil && test.router != "":
+			t.Errorf("%s: no route matched", test.path)
+		case node != nil && node.routerName != test.router:
+			t.Errorf("%s: matched %s, want %q", test.path, node.routerName, test.router)
+		}
+	}
+
+	params := make(Params, 0, 1)
+	root.Get("/users/42", &params)
+	if p := params[0]; p.Key != "id" || p.kind != intConstraint || p.intValue != 42 {
+		t.Errorf("typed param %+v", p)
+	}
+
+	for _, path := range []string{"/users/:num<int>", "/users/:other", "/a/:id<[a-z>", "/a/:id<>"} {
+		if err := root.Put(path); err == nil {
+			t.Errorf("%s: want error", path)
+		}
+	}
+}
@@ -1,6 +1,7 @@
 package web
 
 import (
+	"path"
 	"strings"
 	"unicode"
 	"unsafe"
@@ -8,11 +9,25 @@ import (
 
 func SubStringLast(str, substr string) string {
 	index := strings.Index(str, substr)
-	if index != -1 {
+	if index == -1 {
 		return str
-	} else {
-		return str[index+len(substr):]
 	}
+	return str[index+len(substr):]
+}
+
+// CleanPath 清理路径中的 . .. 和重复的斜杠 保留末尾的斜杠
+func CleanPath(p string) string {
+	if p == "" {
+		return "/"
+	}
+	if p[0] != '/' {
+		p = "/" + p
+	}
+	cleaned := path.Clean(p)
+	if p[len(p)-1] == '/' && cleaned != "/" {
+		cleaned += "/"
+	}
+	return cleaned
 }
 
 func isASCII(s string) bool {
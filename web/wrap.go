@@ -0,0 +1,75 @@
+package web
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Mount 把http.Handler或者另一个*Engine挂载到prefix下
+// 转发前去掉分组和prefix组成的前缀 请求仍然经过分组的中间件
+// 包括自定义方法在内的所有请求方式都会转发
+func (r *routerGroup) Mount(prefix string, handler http.Handler, middlewares ...MiddlewareFunc) {
+	prefix = strings.Trim(prefix, "/")
+	if prefix != "" {
+		prefix = "/" + prefix
+	}
+	strip := r.prefix + prefix
+	h := func(ctx *Context) {
+		req := new(http.Request)
+		*req = *ctx.R
+		req.URL = new(url.URL)
+		*req.URL = *ctx.R.URL
+		req.URL.Path = mountedPath(ctx.R.URL.Path, strip)
+		if req.URL.RawPath != "" {
+			req.URL.RawPath = mountedPath(ctx.R.URL.RawPath, strip)
+		}
+		handler.ServeHTTP(ctx.W, req)
+	}
+	if prefix != "" {
+		r.handle(prefix, anyMethod, h, middlewares...)
+	} else if r.prefix != "" {
+		// 挂载在分组根路径 分组的 / 即前缀本身
+		r.handle("/", anyMethod, h, middlewares...)
+	}
+	r.handle(prefix+"/**", anyMethod, h, middlewares...)
+}
+
+func mountedPath(path, prefix string) string {
+	path = strings.TrimPrefix(path, prefix)
+	if path == "" || path[0] != '/' {
+		path = "/" + path
+	}
+	return path
+}
+
+// WrapHandler 把http.Handler转换为HandlerFunc
+func WrapHandler(h http.Handler) HandlerFunc {
+	return func(ctx *Context) {
+		h.ServeHTTP(ctx.W, ctx.R)
+	}
+}
+
+// WrapF 把http.HandlerFunc转换为HandlerFunc
+func WrapF(f http.HandlerFunc) HandlerFunc {
+	return WrapHandler(f)
+}
+
+// WrapMiddleware 把 func(http.Handler) http.Handler 形式的中间件转换为MiddlewareFunc
+// 中间件替换后的ResponseWriter和Request会继续传给后面的处理函数
+func WrapMiddleware(m func(http.Handler) http.Handler) MiddlewareFunc {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(ctx *Context) {
+			m(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if rw, ok := w.(ResponseWriter); ok {
+					ctx.W = rw
+				} else {
+					// 中间件换了自己的ResponseWriter 包一层继续记录状态
+					ctx.W = &responseWriter{ResponseWriter: w, status: http.StatusOK}
+				}
+				ctx.R = r
+				next(ctx)
+			})).ServeHTTP(ctx.W, ctx.R)
+		}
+	}
+}
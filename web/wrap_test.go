@@ -0,0 +1,77 @@
+package web
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestMount(t *testing.T) {
+	engine := New()
+	var trace []string
+	debug := engine.Group("/debug")
+	debug.MiddlewareHandle(recordMiddleware(&trace, "debug"))
+	debug.Mount("/raw", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("raw " + r.Method + " " + r.URL.Path))
+	}))
+
+	sub := New()
+	sub.Group("/").Get("/users/:id", func(ctx *Context) {
+		ctx.String(http.StatusOK, "sub %s", ctx.Param("id"))
+	})
+	engine.Group("/").Mount("/sub/", sub)
+
+	tests := []struct {
+		method, path string
+		code         int
+		body         string
+	}{
+		{http.MethodGet, "/debug/raw", http.StatusOK, "raw GET /"},
+		{http.MethodPost, "/debug/raw/a/b", http.StatusOK, "raw POST /a/b"},
+		{"PROPFIND", "/debug/raw/dav", http.StatusOK, "raw PROPFIND /dav"},
+		{http.MethodGet, "/sub/users/7", http.StatusOK, "sub 7"},
+		{http.MethodGet, "/sub/missing", http.StatusNotFound, ""},
+	}
+	for _, test := range tests {
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, httptest.NewRequest(test.method, test.path, nil))
+		if w.Code != test.code || test.code == http.StatusOK && w.Body.String() != test.body {
+			t.Errorf("%s %s: code %d body %q, want %d %q", test.method, test.path, w.Code, w.Body.String(), test.code, test.body)
+		}
+	}
+	if got := strings.Join(trace, ","); got != "debug,debug,debug" {
+		t.Errorf("middleware trace %s", got)
+	}
+}
+
+func TestWrapMiddleware(t *testing.T) {
+	engine := New()
+	g := engine.Group("/")
+	g.MiddlewareHandle(WrapMiddleware(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("X-Token") == "" {
+				http.Error(w, "forbidden", http.StatusForbidden)
+				return
+			}
+			w.Header().Set("X-Wrapped", "yes")
+			next.ServeHTTP(w, r)
+		})
+	}))
+	g.Get("/", WrapF(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	}))
+
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	if w.Code != http.StatusForbidden {
+		t.Errorf("without token: code %d", w.Code)
+	}
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("X-Token", "1")
+	w = httptest.NewRecorder()
+	engine.ServeHTTP(w, r)
+	if w.Body.String() != "ok" || w.Header().Get("X-Wrapped") != "yes" {
+		t.Errorf("with token: body %q header %v", w.Body.String(), w.Header())
+	}
+}